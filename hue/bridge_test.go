@@ -0,0 +1,71 @@
+package hue
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+)
+
+// testBridge is a fake bridge which answers "METHOD /path" (the part after /api/<username>) with a canned response
+// and records every request.  Anything it has no response for gets the bridge's "not available" error.
+type testBridge struct {
+	*httptest.Server
+
+	responses map[string]string
+
+	mutex    sync.Mutex
+	requests []testRequest
+}
+
+type testRequest struct {
+	method string
+	path   string
+	body   string
+}
+
+// newTestBridge starts a fake bridge, which has to be closed when the test is done with it.
+func newTestBridge(responses map[string]string) (*testBridge, *common.Connection) {
+	bridge := &testBridge{responses: responses}
+	bridge.Server = httptest.NewServer(bridge)
+
+	return bridge, &common.Connection{Host: strings.TrimPrefix(bridge.URL, "http://"), Username: "test"}
+}
+
+func (b *testBridge) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	path := strings.TrimPrefix(r.URL.Path, "/api/test")
+
+	b.mutex.Lock()
+	b.requests = append(b.requests, testRequest{method: r.Method, path: path, body: string(body)})
+	b.mutex.Unlock()
+
+	response, ok := b.responses[r.Method+" "+path]
+
+	if !ok {
+		response = fmt.Sprintf(`[{"error": {"type": 3, "address": %q, "description": "resource, %s, not available"}}]`, path, path)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(response))
+}
+
+// sent returns the bodies of the requests made with method, keyed by path.
+func (b *testBridge) sent(method string) map[string]string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	bodies := make(map[string]string)
+
+	for _, request := range b.requests {
+		if request.method == method {
+			bodies[request.path] = request.body
+		}
+	}
+
+	return bodies
+}
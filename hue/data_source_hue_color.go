@@ -0,0 +1,193 @@
+package hue
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/color"
+)
+
+// colorInputs are the ways a colour can be given to philips-hue_color; exactly one is used.
+var colorInputs = []string{"color", "xy", "hue", "kelvin", "ct"}
+
+func dataSourceHueColor() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceHueColorRead,
+
+		Schema: map[string]*schema.Schema{
+			"gamut": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				Default:      "C",
+				ValidateFunc: validation.StringInSlice([]string{"A", "B", "C"}, false),
+				Description:  "Gamut the xy is fitted to.",
+			},
+
+			// Each of these is either given, and left as it is, or worked out from whichever one was.
+			"color": {
+				Type:          schema.TypeString,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"xy", "hue", "sat", "kelvin", "ct"},
+				ValidateFunc:  validateColor,
+				Description:   "A colour as #rrggbb, #rgb, rgb(r, g, b) or a CSS colour name.",
+			},
+			"xy": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"color", "hue", "sat", "kelvin", "ct"},
+				Elem:          &schema.Schema{Type: schema.TypeFloat},
+				MinItems:      2,
+				MaxItems:      2,
+			},
+			"hue": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"color", "xy", "kelvin", "ct"},
+				ValidateFunc:  validation.IntBetween(0, 65535),
+			},
+			"sat": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"color", "xy", "kelvin", "ct"},
+				ValidateFunc:  validation.IntBetween(0, 254),
+			},
+			"bri": {
+				Type:         schema.TypeInt,
+				Optional:     true,
+				Computed:     true,
+				ValidateFunc: validation.IntBetween(1, 254),
+				Description:  "Brightness of the colour.  Given with any of the others, hex and rgb are at this brightness.",
+			},
+			"kelvin": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"color", "xy", "hue", "sat", "ct"},
+				ValidateFunc:  validation.IntBetween(2000, 6535),
+			},
+			"ct": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				Computed:      true,
+				ConflictsWith: []string{"color", "xy", "hue", "sat", "kelvin"},
+				ValidateFunc:  validation.IntBetween(153, 500),
+				Description:   "Colour temperature in mireds.",
+			},
+
+			"hex": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"rgb": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeInt},
+			},
+		},
+	}
+}
+
+func dataSourceHueColorRead(d *schema.ResourceData, meta interface{}) error {
+	gamutType := d.Get("gamut").(string)
+	gamut := color.GamutFor(gamutType)
+
+	given := make(map[string]bool)
+
+	var rgb color.RGB
+	var xy color.XY
+	var kelvin int
+
+	switch {
+	case d.Get("color").(string) != "":
+		parsed, err := color.Parse(d.Get("color").(string))
+
+		if err != nil {
+			return err
+		}
+
+		given["color"] = true
+		rgb = parsed
+		xy = rgb.XY(gamut)
+	case len(d.Get("xy").([]interface{})) == 2:
+		given["xy"] = true
+		xy = gamut.Clamp(color.XY{X: d.Get("xy.0").(float64), Y: d.Get("xy.1").(float64)})
+		rgb = color.FromXY(xy)
+	case isSet(d, "hue") || isSet(d, "sat"):
+		hsb := color.HSB{Hue: 0, Sat: 254, Bri: 254}
+
+		if hue, ok := d.GetOkExists("hue"); ok {
+			given["hue"] = true
+			hsb.Hue = hue.(int)
+		}
+
+		if sat, ok := d.GetOkExists("sat"); ok {
+			given["sat"] = true
+			hsb.Sat = sat.(int)
+		}
+
+		rgb = hsb.RGB()
+		xy = rgb.XY(gamut)
+	case d.Get("kelvin").(int) != 0 || d.Get("ct").(int) != 0:
+		if d.Get("kelvin").(int) != 0 {
+			given["kelvin"] = true
+			kelvin = d.Get("kelvin").(int)
+		} else {
+			given["ct"] = true
+			kelvin = color.MiredsToKelvin(d.Get("ct").(int))
+		}
+
+		xy = gamut.Clamp(color.KelvinToXY(kelvin))
+		rgb = color.FromXY(xy)
+	default:
+		return fmt.Errorf("one of %v is required", colorInputs)
+	}
+
+	if bri, ok := d.GetOk("bri"); ok {
+		given["bri"] = true
+		rgb = rgb.WithBri(bri.(int))
+	}
+
+	if kelvin == 0 {
+		kelvin = clampInt(color.XYToKelvin(xy), 2000, 6535)
+	}
+
+	hsb := rgb.HSB()
+
+	computed := map[string]interface{}{
+		"color":  rgb.Hex(),
+		"xy":     []interface{}{roundXY(xy.X), roundXY(xy.Y)},
+		"hue":    hsb.Hue,
+		"sat":    hsb.Sat,
+		"bri":    hsb.Bri,
+		"kelvin": kelvin,
+		"ct":     clampInt(color.KelvinToMireds(kelvin), 153, 500),
+		"hex":    rgb.Hex(),
+		"rgb":    []interface{}{int(rgb.R), int(rgb.G), int(rgb.B)},
+	}
+
+	for key, value := range computed {
+		if given[key] {
+			continue
+		}
+
+		if err := d.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", rgb.Hex(), gamutType))
+
+	return nil
+}
+
+// isSet reports whether an attribute is in the configuration, even if it's 0.
+func isSet(d *schema.ResourceData, key string) bool {
+	_, ok := d.GetOkExists(key)
+
+	return ok
+}
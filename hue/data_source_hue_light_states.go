@@ -0,0 +1,189 @@
+package hue
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/ghue/sdk/scenes"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+func dataSourceHueLightStates() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceHueLightStatesRead,
+
+		Schema: map[string]*schema.Schema{
+			"light_ids": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"light_state": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: computedLightStateSchema(),
+				},
+			},
+			"hcl": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The light states as light_state blocks, ready to paste into a philips-hue_scene.",
+			},
+		},
+	}
+}
+
+// computedLightStateSchema is a light_state block as read back from the bridge by data sources.
+func computedLightStateSchema() map[string]*schema.Schema {
+	lightStateSchema := lightStateSchema()
+
+	lightStateSchema["light_id"] = &schema.Schema{
+		Type: schema.TypeString,
+	}
+
+	// What the lights show is read back in the bridge's own units, never as a colour, temperature or percentage.
+	for _, key := range []string{"color", "kelvin", "stored_xy", "stored_ct", "brightness_percent", "transition"} {
+		delete(lightStateSchema, key)
+	}
+
+	// Everything in a light_state is read from the bridge.
+	for _, attribute := range lightStateSchema {
+		attribute.Optional = false
+		attribute.Computed = true
+		attribute.ValidateFunc = nil
+		attribute.MinItems = 0
+		attribute.MaxItems = 0
+		attribute.DiffSuppressFunc = nil
+		attribute.Default = nil
+	}
+
+	return lightStateSchema
+}
+
+// flattenComputedLightState is flattenLightState for a computed light_state.  unsetLightStateInt only stands for an
+// unset hue, sat or transitiontime where it's configured, so here they're left out instead.
+func flattenComputedLightState(lightId string, lightState scenes.LightState) map[string]interface{} {
+	state := flattenLightState(lightState)
+	state["light_id"] = lightId
+
+	for _, key := range []string{"hue", "sat", "transitiontime"} {
+		if state[key] == unsetLightStateInt {
+			delete(state, key)
+		}
+	}
+
+	return state
+}
+
+func dataSourceHueLightStatesRead(d *schema.ResourceData, meta interface{}) error {
+	connection := meta.(*common.Connection)
+
+	lightIds := dataToLightArray(d.Get("light_ids").(*schema.Set))
+	sort.Strings(lightIds)
+
+	var lightStates []map[string]interface{}
+	var hcl []string
+
+	for _, lightId := range lightIds {
+		lightState, err := currentLightState(connection, lightId)
+
+		if err != nil {
+			return err
+		}
+
+		state := flattenComputedLightState(lightId, lightState)
+
+		if state["effect"] == effectNone {
+			delete(state, "effect")
+		}
+
+		lightStates = append(lightStates, state)
+		hcl = append(hcl, lightStateHCL(state))
+	}
+
+	if err := d.Set("light_state", lightStates); err != nil {
+		return err
+	}
+
+	if err := d.Set("hcl", strings.Join(hcl, "\n")); err != nil {
+		return err
+	}
+
+	d.SetId(strings.Join(lightIds, ","))
+
+	return nil
+}
+
+// currentLightState reads what a light is showing, keeping only the values for its current colour mode so that the
+// result is valid as a light_state.
+func currentLightState(connection *common.Connection, lightId string) (scenes.LightState, error) {
+	var raw json.RawMessage
+
+	if err := bridge.Get(connection, "/lights/"+lightId, &raw); err != nil {
+		return scenes.LightState{}, err
+	}
+
+	var light struct {
+		State scenes.LightState `json:"state"`
+	}
+
+	var mode struct {
+		State struct {
+			ColorMode string `json:"colormode"`
+		} `json:"state"`
+	}
+
+	if err := json.Unmarshal(raw, &light); err != nil {
+		return scenes.LightState{}, err
+	}
+
+	if err := json.Unmarshal(raw, &mode); err != nil {
+		return scenes.LightState{}, err
+	}
+
+	lightState := light.State
+
+	switch mode.State.ColorMode {
+	case "xy":
+		lightState.Hue, lightState.Sat, lightState.CT = nil, nil, nil
+	case "ct":
+		lightState.Hue, lightState.Sat, lightState.XY = nil, nil, nil
+	case "hs":
+		lightState.XY, lightState.CT = nil, nil
+	}
+
+	return lightState, nil
+}
+
+func lightStateHCL(state map[string]interface{}) string {
+	lines := []string{"light_state {"}
+
+	for _, key := range append([]string{"light_id"}, lightStateKeys...) {
+		value, ok := state[key]
+
+		if !ok {
+			continue
+		}
+
+		switch value := value.(type) {
+		case []interface{}:
+			lines = append(lines, fmt.Sprintf("    %s = [%v, %v]", key, value[0], value[1]))
+		case int:
+			if expandLightStateInt(value) != nil {
+				lines = append(lines, fmt.Sprintf("    %s = %d", key, value))
+			}
+		default:
+			lines = append(lines, fmt.Sprintf("    %s = %q", key, value))
+		}
+	}
+
+	lines = append(lines, "}")
+
+	return strings.Join(lines, "\n")
+}
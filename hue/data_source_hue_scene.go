@@ -0,0 +1,156 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+func dataSourceHueScene() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceHueSceneRead,
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "Only look at scenes in this group, since the Hue app gives every room's scenes the same names.",
+			},
+
+			"type": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lights": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"light_state": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: computedLightStateSchema(),
+				},
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"locked": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"lastupdated": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceHueSceneRead(d *schema.ResourceData, meta interface{}) error {
+	connection := meta.(*common.Connection)
+
+	name := d.Get("name").(string)
+	groupId := d.Get("group").(string)
+
+	sceneId, err := findScene(connection, name, groupId)
+
+	if err != nil {
+		return err
+	}
+
+	// Light states are only returned when fetching a single scene.
+	var scene bridgeScene
+
+	if err := bridge.Get(connection, "/scenes/"+sceneId, &scene); err != nil {
+		return err
+	}
+
+	lightIds := make([]string, 0, len(scene.Lightstates))
+
+	for lightId := range scene.Lightstates {
+		lightIds = append(lightIds, lightId)
+	}
+
+	sort.Strings(lightIds)
+
+	lightStates := make([]map[string]interface{}, 0, len(lightIds))
+
+	for _, lightId := range lightIds {
+		state := flattenComputedLightState(lightId, scene.Lightstates[lightId])
+
+		lightStates = append(lightStates, state)
+	}
+
+	sceneType := scene.Type
+
+	if sceneType == "" {
+		sceneType = lightScene
+	}
+
+	values := map[string]interface{}{
+		"type":        sceneType,
+		"lights":      scene.Lights,
+		"light_state": lightStates,
+		"owner":       scene.Owner,
+		"locked":      scene.Locked,
+		"lastupdated": scene.LastUpdated,
+	}
+
+	for key, value := range values {
+		if err := d.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	d.SetId(sceneId)
+
+	return nil
+}
+
+// findScene returns the id of the only scene with the name, in the group if one is given.
+func findScene(connection *common.Connection, name string, groupId string) (string, error) {
+	var scenes map[string]struct {
+		Name  string `json:"name"`
+		Group string `json:"group"`
+	}
+
+	if err := bridge.Get(connection, "/scenes", &scenes); err != nil {
+		return "", err
+	}
+
+	var matches []string
+
+	for id, scene := range scenes {
+		if scene.Name == name && (groupId == "" || scene.Group == groupId) {
+			matches = append(matches, id)
+		}
+	}
+
+	where := ""
+
+	if groupId != "" {
+		where = " in group " + groupId
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no scene named %q found%s", name, where)
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", fmt.Errorf("%d scenes named %q found%s (ids %s); set group to choose between them", len(matches), name,
+			where, strings.Join(matches, ", "))
+	}
+}
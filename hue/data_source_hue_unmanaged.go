@@ -0,0 +1,193 @@
+package hue
+
+import (
+	"sort"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+// Objects the bridge lists along with who created them.  Groups have no owner, and nor do sensors built into the bridge.
+type ownedObject struct {
+	Name  string `json:"name"`
+	Owner string `json:"owner"`
+}
+
+func dataSourceHueUnmanaged() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceHueUnmanagedRead,
+
+		Schema: map[string]*schema.Schema{
+			"managed_scene_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"managed_rule_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"managed_group_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"managed_schedule_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+			"managed_sensor_ids": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Set:      schema.HashString,
+			},
+
+			"scenes":    unmanagedObjectsSchema(),
+			"rules":     unmanagedObjectsSchema(),
+			"groups":    unmanagedObjectsSchema(),
+			"schedules": unmanagedObjectsSchema(),
+			"sensors":   unmanagedObjectsSchema(),
+
+			"orphaned_scene_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Scenes created by this provider's user which aren't in managed_scene_ids.",
+			},
+			"orphaned_rule_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Rules created by this provider's user which aren't in managed_rule_ids.",
+			},
+			"orphaned_schedule_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Schedules created by this provider's user which aren't in managed_schedule_ids.",
+			},
+			"orphaned_sensor_ids": {
+				Type:        schema.TypeList,
+				Computed:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Sensors created by this provider's user which aren't in managed_sensor_ids.",
+			},
+		},
+	}
+}
+
+func unmanagedObjectsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"id": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"name": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"owner": {
+					Type:     schema.TypeString,
+					Computed: true,
+				},
+				"created_by_provider": {
+					Type:     schema.TypeBool,
+					Computed: true,
+				},
+			},
+		},
+	}
+}
+
+func dataSourceHueUnmanagedRead(d *schema.ResourceData, meta interface{}) error {
+	connection := meta.(*common.Connection)
+
+	lists := []struct {
+		path      string
+		managed   string
+		unmanaged string
+		orphaned  string
+	}{
+		{"/scenes", "managed_scene_ids", "scenes", "orphaned_scene_ids"},
+		{"/rules", "managed_rule_ids", "rules", "orphaned_rule_ids"},
+		{"/groups", "managed_group_ids", "groups", ""},
+		{"/schedules", "managed_schedule_ids", "schedules", "orphaned_schedule_ids"},
+		{"/sensors", "managed_sensor_ids", "sensors", "orphaned_sensor_ids"},
+	}
+
+	for _, list := range lists {
+		var objects map[string]ownedObject
+
+		err := bridge.Get(connection, list.path, &objects)
+
+		if err != nil {
+			return err
+		}
+
+		managed := d.Get(list.managed).(*schema.Set)
+
+		unmanaged := make([]map[string]interface{}, 0)
+		orphaned := make([]string, 0)
+
+		for _, id := range sortedIds(objects) {
+			if managed.Contains(id) {
+				continue
+			}
+
+			object := objects[id]
+
+			// The bridge records the whitelist user that created scenes, rules, schedules and CLIP sensors, which for
+			// anything made by Terraform is the provider's hub_username.
+			createdByProvider := object.Owner != "" && object.Owner == connection.Username
+
+			unmanaged = append(unmanaged, map[string]interface{}{
+				"id":                  id,
+				"name":                object.Name,
+				"owner":               object.Owner,
+				"created_by_provider": createdByProvider,
+			})
+
+			if createdByProvider {
+				orphaned = append(orphaned, id)
+			}
+		}
+
+		if err := d.Set(list.unmanaged, unmanaged); err != nil {
+			return err
+		}
+
+		if list.orphaned != "" {
+			if err := d.Set(list.orphaned, orphaned); err != nil {
+				return err
+			}
+		}
+	}
+
+	d.SetId(connection.Host)
+
+	return nil
+}
+
+func sortedIds(objects map[string]ownedObject) []string {
+	ids := make([]string, 0, len(objects))
+
+	for id := range objects {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
@@ -0,0 +1,160 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/resource"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+// bridgeCommand is what a rule action or schedule does.
+type bridgeCommand struct {
+	Address string                 `json:"address"`
+	Body    map[string]interface{} `json:"body"`
+}
+
+// bridgeDependentRule and bridgeDependentSchedule have just what's needed to tell what they refer to.
+type bridgeDependentRule struct {
+	Name       string `json:"name"`
+	Conditions []struct {
+		Address string `json:"address"`
+	} `json:"conditions"`
+	Actions []bridgeCommand `json:"actions"`
+}
+
+type bridgeDependentSchedule struct {
+	Name    string        `json:"name"`
+	Command bridgeCommand `json:"command"`
+}
+
+// dependent is a rule or schedule which refers to a scene or group.
+type dependent struct {
+	kind string
+	id   string
+	name string
+}
+
+func (d dependent) String() string {
+	return fmt.Sprintf("%s %s (%s)", d.kind, d.id, d.name)
+}
+
+// waitForDependentsSchema is the attribute which makes deleting a scene or group wait for what refers to it to go.
+func waitForDependentsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Description: "Wait up to the delete timeout for rules and schedules that refer to this to be removed, e.g. by " +
+			"the same apply, instead of failing straight away.",
+	}
+}
+
+// checkDependents fails if any rule or schedule refers to path (e.g. "/scenes/1"), or to sceneId in a body, since the
+// bridge won't delete something that's in use.  With wait_for_dependents it keeps checking until the delete times out.
+func checkDependents(d *schema.ResourceData, connection *common.Connection, kind string, path string, sceneId string) error {
+	check := func() (bool, error) {
+		dependents, err := findDependents(connection, path, sceneId)
+
+		if err != nil {
+			return false, err
+		}
+
+		if len(dependents) == 0 {
+			return false, nil
+		}
+
+		names := make([]string, 0, len(dependents))
+
+		for _, dependent := range dependents {
+			names = append(names, dependent.String())
+		}
+
+		return true, fmt.Errorf("%s %s can't be deleted while it's used by %s; remove it from them first, or set "+
+			"wait_for_dependents if they're being removed by this apply", kind, d.Id(), strings.Join(names, ", "))
+	}
+
+	if !d.Get("wait_for_dependents").(bool) {
+		_, err := check()
+		return err
+	}
+
+	return resource.Retry(d.Timeout(schema.TimeoutDelete), func() *resource.RetryError {
+		retry, err := check()
+
+		if retry {
+			return resource.RetryableError(err)
+		}
+
+		if err != nil {
+			return resource.NonRetryableError(err)
+		}
+
+		return nil
+	})
+}
+
+// findDependents lists the rules and schedules which refer to path or, if it's set, to sceneId in a body.
+func findDependents(connection *common.Connection, path string, sceneId string) ([]dependent, error) {
+	var rules map[string]bridgeDependentRule
+	var schedules map[string]bridgeDependentSchedule
+
+	if err := bridge.Get(connection, "/rules", &rules); err != nil {
+		return nil, err
+	}
+
+	if err := bridge.Get(connection, "/schedules", &schedules); err != nil {
+		return nil, err
+	}
+
+	var dependents []dependent
+
+	for id, rule := range rules {
+		refers := false
+
+		for _, condition := range rule.Conditions {
+			refers = refers || refersToPath(condition.Address, path)
+		}
+
+		for _, action := range rule.Actions {
+			refers = refers || action.refersTo(path, sceneId)
+		}
+
+		if refers {
+			dependents = append(dependents, dependent{kind: "rule", id: id, name: rule.Name})
+		}
+	}
+
+	for id, schedule := range schedules {
+		if schedule.Command.refersTo(path, sceneId) {
+			dependents = append(dependents, dependent{kind: "schedule", id: id, name: schedule.Name})
+		}
+	}
+
+	sort.Slice(dependents, func(i, j int) bool {
+		return dependents[i].String() < dependents[j].String()
+	})
+
+	return dependents, nil
+}
+
+// refersToPath matches path itself and anything under it.  Schedule addresses include /api/<username>.
+func refersToPath(address string, path string) bool {
+	if i := strings.Index(address, path); i >= 0 {
+		rest := address[i+len(path):]
+
+		return rest == "" || strings.HasPrefix(rest, "/")
+	}
+
+	return false
+}
+
+func (command bridgeCommand) refersTo(path string, sceneId string) bool {
+	if refersToPath(command.Address, path) {
+		return true
+	}
+
+	return sceneId != "" && command.Body["scene"] == sceneId
+}
@@ -0,0 +1,70 @@
+package hue
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestRefersToPath(t *testing.T) {
+	cases := []struct {
+		address string
+		path    string
+		want    bool
+	}{
+		{"/groups/1/action", "/groups/1", true},
+		{"/api/user/groups/1/action", "/groups/1", true},
+		{"/groups/1", "/groups/1", true},
+		{"/groups/10/action", "/groups/1", false},
+		{"/groups/2/action", "/groups/1", false},
+		{"/sensors/1/state/buttonevent", "/groups/1", false},
+		{"", "/groups/1", false},
+	}
+
+	for _, c := range cases {
+		if got := refersToPath(c.address, c.path); got != c.want {
+			t.Errorf("refersToPath(%q, %q) = %t, want %t", c.address, c.path, got, c.want)
+		}
+	}
+}
+
+func TestFindDependents(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{
+		"GET /rules": `{
+			"1": {"name": "Switch on", "conditions": [{"address": "/sensors/2/state/buttonevent"}],
+				"actions": [{"address": "/groups/1/action", "body": {"scene": "abc"}}]},
+			"2": {"name": "Motion", "conditions": [{"address": "/groups/1/state/any_on"}],
+				"actions": [{"address": "/groups/3/action", "body": {"on": true}}]},
+			"3": {"name": "Other room", "conditions": [{"address": "/groups/10/state/any_on"}],
+				"actions": [{"address": "/groups/10/action", "body": {"scene": "def"}}]}
+		}`,
+		"GET /schedules": `{
+			"4": {"name": "Wake up", "command": {"address": "/api/test/groups/0/action", "body": {"scene": "abc"}}},
+			"5": {"name": "Lights out", "command": {"address": "/api/test/groups/0/action", "body": {"on": false}}}
+		}`,
+	})
+	defer bridge.Close()
+
+	cases := []struct {
+		path    string
+		sceneId string
+		want    string
+	}{
+		{"/scenes/abc", "abc", "[rule 1 (Switch on) schedule 4 (Wake up)]"},
+		{"/groups/1", "", "[rule 1 (Switch on) rule 2 (Motion)]"},
+		{"/groups/3", "", "[rule 2 (Motion)]"},
+		{"/groups/5", "", "[]"},
+	}
+
+	for _, c := range cases {
+		dependents, err := findDependents(connection, c.path, c.sceneId)
+
+		if err != nil {
+			t.Errorf("findDependents(%q, %q): %s", c.path, c.sceneId, err)
+			continue
+		}
+
+		if got := fmt.Sprint(dependents); got != c.want {
+			t.Errorf("findDependents(%q, %q) = %s, want %s", c.path, c.sceneId, got, c.want)
+		}
+	}
+}
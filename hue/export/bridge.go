@@ -0,0 +1,121 @@
+package export
+
+import (
+	"fmt"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+type light struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type sensor struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type group struct {
+	Name   string   `json:"name"`
+	Lights []string `json:"lights"`
+	Type   string   `json:"type"`
+}
+
+type sceneLightState struct {
+	On             *bool     `json:"on"`
+	Bri            *int      `json:"bri"`
+	Hue            *int      `json:"hue"`
+	Sat            *int      `json:"sat"`
+	XY             []float64 `json:"xy"`
+	CT             *int      `json:"ct"`
+	Effect         *string   `json:"effect"`
+	TransitionTime *int      `json:"transitiontime"`
+}
+
+type scene struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Group   string   `json:"group"`
+	Lights  []string `json:"lights"`
+	Recycle bool     `json:"recycle"`
+	Picture string   `json:"picture"`
+	AppData struct {
+		Version int    `json:"version"`
+		Data    string `json:"data"`
+	} `json:"appdata"`
+	Lightstates map[string]sceneLightState `json:"lightstates"`
+}
+
+type condition struct {
+	Address  string  `json:"address"`
+	Operator string  `json:"operator"`
+	Value    *string `json:"value"`
+}
+
+type action struct {
+	Address string                 `json:"address"`
+	Method  string                 `json:"method"`
+	Body    map[string]interface{} `json:"body"`
+}
+
+type rule struct {
+	Name       string      `json:"name"`
+	Conditions []condition `json:"conditions"`
+	Actions    []action    `json:"actions"`
+}
+
+type schedule struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Command     action `json:"command"`
+	Localtime   string `json:"localtime"`
+	Status      string `json:"status"`
+}
+
+// configuration is everything on the bridge that's exported, keyed by id.
+type configuration struct {
+	Lights    map[string]light
+	Sensors   map[string]sensor
+	Groups    map[string]group
+	Scenes    map[string]scene
+	Rules     map[string]rule
+	Schedules map[string]schedule
+}
+
+func readConfiguration(connection *common.Connection) (*configuration, error) {
+	var config configuration
+
+	lists := []struct {
+		path   string
+		result interface{}
+	}{
+		{"/lights", &config.Lights},
+		{"/sensors", &config.Sensors},
+		{"/groups", &config.Groups},
+		{"/scenes", &config.Scenes},
+		{"/rules", &config.Rules},
+		{"/schedules", &config.Schedules},
+	}
+
+	for _, list := range lists {
+		if err := bridge.Get(connection, list.path, list.result); err != nil {
+			return nil, fmt.Errorf("reading %s: %s", list.path, err)
+		}
+	}
+
+	// Light states are only returned when fetching a single scene.
+	for id, s := range config.Scenes {
+		var full scene
+
+		if err := bridge.Get(connection, "/scenes/"+id, &full); err != nil {
+			return nil, fmt.Errorf("reading scene %s: %s", id, err)
+		}
+
+		s.Lightstates = full.Lightstates
+		config.Scenes[id] = s
+	}
+
+	return &config, nil
+}
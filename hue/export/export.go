@@ -0,0 +1,515 @@
+// Package export generates Terraform configuration for everything already on a bridge, so an existing setup can be
+// brought under management with `terraform import`.
+package export
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+)
+
+// Main runs the export subcommand, e.g. `terraform-provider-philips-hue export --address ... --username ...`
+func Main(args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+
+	address := flags.String("address", "", "Address of your Philips Hue Hub")
+	username := flags.String("username", "", "Username on your Hub")
+	output := flags.String("output", ".", "Directory the .tf files and import script are written to")
+	verbose := flags.Bool("verbose", false, "Log every request made to the Hub")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *address == "" || *username == "" {
+		return fmt.Errorf("both --address and --username are required")
+	}
+
+	connection := &common.Connection{
+		Host:     *address,
+		Username: *username,
+		Verbose:  *verbose,
+	}
+
+	config, err := readConfiguration(connection)
+
+	if err != nil {
+		return err
+	}
+
+	files := newExporter(config, *address).files()
+
+	if err := os.MkdirAll(*output, 0755); err != nil {
+		return err
+	}
+
+	for _, name := range sortedKeys(files) {
+		path := filepath.Join(*output, name)
+
+		mode := os.FileMode(0644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0755
+		}
+
+		if err := ioutil.WriteFile(path, []byte(files[name]), mode); err != nil {
+			return err
+		}
+
+		fmt.Printf("Wrote %s\n", path)
+	}
+
+	return nil
+}
+
+const (
+	lightDataSource  = "data.philips-hue_light"
+	sensorDataSource = "data.philips-hue_sensor"
+	groupResource    = "philips-hue_group"
+	sceneResource    = "philips-hue_scene"
+	ruleResource     = "philips-hue_rule"
+)
+
+type exporter struct {
+	config     *configuration
+	hubAddress string
+
+	// names maps a resource type and bridge id to the unique Terraform name generated for it.
+	names map[string]map[string]string
+
+	imports []string
+}
+
+func newExporter(config *configuration, address string) *exporter {
+	e := &exporter{
+		config:     config,
+		hubAddress: address,
+		names:      make(map[string]map[string]string),
+	}
+
+	lightNames := make(map[string]string)
+	for id, l := range config.Lights {
+		lightNames[id] = l.Name
+	}
+
+	sensorNames := make(map[string]string)
+	for id, s := range config.Sensors {
+		sensorNames[id] = s.Name
+	}
+
+	groupNames := make(map[string]string)
+	for id, g := range config.Groups {
+		groupNames[id] = g.Name
+	}
+
+	sceneNames := make(map[string]string)
+	for id, s := range config.Scenes {
+		sceneNames[id] = s.Name
+	}
+
+	ruleNames := make(map[string]string)
+	for id, r := range config.Rules {
+		ruleNames[id] = r.Name
+	}
+
+	e.assignNames(lightDataSource, lightNames)
+	e.assignNames(sensorDataSource, sensorNames)
+	e.assignNames(groupResource, groupNames)
+	e.assignNames(sceneResource, sceneNames)
+	e.assignNames(ruleResource, ruleNames)
+
+	return e
+}
+
+// files returns the generated configuration, keyed by file name.
+func (e *exporter) files() map[string]string {
+	files := map[string]string{
+		"provider.tf":  e.provider(),
+		"lights.tf":    e.lights(),
+		"sensors.tf":   e.sensors(),
+		"groups.tf":    e.groups(),
+		"scenes.tf":    e.scenes(),
+		"rules.tf":     e.rules(),
+		"schedules.tf": e.schedules(),
+	}
+
+	files["import.sh"] = "#!/bin/sh\nset -e\n\n" + strings.Join(e.imports, "\n") + "\n"
+
+	return files
+}
+
+func (e *exporter) provider() string {
+	w := &writer{}
+
+	w.open(`variable "hub_username"`)
+	w.attribute("description", quote("Username on your Hub"))
+	w.close()
+	w.blank()
+
+	w.open(`provider "philips-hue"`)
+	w.attribute("hub_address", quote(e.hubAddress))
+	w.attribute("hub_username", `"${var.hub_username}"`)
+	w.close()
+
+	return w.String()
+}
+
+func (e *exporter) lights() string {
+	w := &writer{}
+
+	for _, id := range sortedKeys(e.config.Lights) {
+		w.open(fmt.Sprintf(`data "philips-hue_light" %q`, e.names[lightDataSource][id]))
+		w.comment(e.config.Lights[id].Name)
+		w.attribute("light_id", quote(id))
+		w.close()
+		w.blank()
+	}
+
+	return w.String()
+}
+
+func (e *exporter) sensors() string {
+	w := &writer{}
+
+	for _, id := range sortedKeys(e.config.Sensors) {
+		w.open(fmt.Sprintf(`data "philips-hue_sensor" %q`, e.names[sensorDataSource][id]))
+		w.comment(fmt.Sprintf("%s (%s)", e.config.Sensors[id].Name, e.config.Sensors[id].Type))
+		w.attribute("sensor_id", quote(id))
+		w.close()
+		w.blank()
+	}
+
+	return w.String()
+}
+
+func (e *exporter) groups() string {
+	w := &writer{}
+
+	for _, id := range sortedKeys(e.config.Groups) {
+		g := e.config.Groups[id]
+
+		e.open(w, groupResource, id)
+		w.attribute("name", quote(g.Name))
+		w.attribute("lights", e.lightList(g.Lights))
+		w.attribute("type", quote(g.Type))
+		w.close()
+		w.blank()
+	}
+
+	return w.String()
+}
+
+func (e *exporter) scenes() string {
+	w := &writer{}
+
+	for _, id := range sortedKeys(e.config.Scenes) {
+		s := e.config.Scenes[id]
+
+		e.open(w, sceneResource, id)
+		w.attribute("name", quote(s.Name))
+		w.attribute("recycle", strconv.FormatBool(s.Recycle))
+
+		if s.Type == "GroupScene" {
+			w.attribute("type", quote(s.Type))
+			w.attribute("group", e.reference(groupResource, s.Group))
+		}
+
+		if s.AppData.Version != 0 || s.AppData.Data != "" {
+			w.attribute("appdata_version", strconv.Itoa(s.AppData.Version))
+			w.attribute("appdata_data", quote(s.AppData.Data))
+		}
+
+		if s.Picture != "" {
+			w.attribute("picture", quote(s.Picture))
+		}
+
+		for _, lightId := range sortedKeys(s.Lightstates) {
+			state := s.Lightstates[lightId]
+
+			w.blank()
+			w.open("light_state")
+			w.attribute("light_id", e.reference(lightDataSource, lightId))
+
+			if state.On != nil {
+				w.attribute("state", quote(onOff(*state.On)))
+			}
+
+			attributes := []intAttribute{
+				{"bri", state.Bri},
+				{"transitiontime", state.TransitionTime},
+			}
+
+			// The bridge may report more than one colour mode, but only one of xy/ct/hue+sat is allowed in a
+			// light_state.  xy is the most precise, so it wins.
+			if len(state.XY) != 2 {
+				if state.CT != nil {
+					attributes = append(attributes, intAttribute{"ct", state.CT})
+				} else {
+					attributes = append(attributes, intAttribute{"hue", state.Hue}, intAttribute{"sat", state.Sat})
+				}
+			}
+
+			for _, attribute := range attributes {
+				if attribute.value != nil {
+					w.attribute(attribute.name, strconv.Itoa(*attribute.value))
+				}
+			}
+
+			if len(state.XY) == 2 {
+				w.attribute("xy", floatList(state.XY))
+			}
+
+			// The bridge reports "none" for colour lights without an effect, which is the same as leaving it out.
+			if state.Effect != nil && *state.Effect != "none" {
+				w.attribute("effect", quote(*state.Effect))
+			}
+
+			w.close()
+		}
+
+		w.close()
+		w.blank()
+	}
+
+	return w.String()
+}
+
+type intAttribute struct {
+	name  string
+	value *int
+}
+
+func (e *exporter) rules() string {
+	w := &writer{}
+
+	for _, id := range sortedKeys(e.config.Rules) {
+		r := e.config.Rules[id]
+
+		e.open(w, ruleResource, id)
+		w.attribute("name", quote(r.Name))
+
+		for _, c := range r.Conditions {
+			w.blank()
+			w.open("condition")
+			w.attribute("address", e.address(c.Address))
+			w.attribute("operator", quote(c.Operator))
+
+			if c.Value != nil {
+				w.attribute("value", quote(*c.Value))
+			}
+
+			w.close()
+		}
+
+		for _, a := range r.Actions {
+			w.blank()
+			e.action(w, "action", a)
+		}
+
+		w.close()
+		w.blank()
+	}
+
+	return w.String()
+}
+
+// Schedules aren't managed by this provider yet, so they're written out commented so nothing is lost in the move.
+func (e *exporter) schedules() string {
+	w := &writer{}
+
+	w.comment("Schedules are not supported by the provider yet; they are listed here for reference only.")
+	w.blank()
+
+	for _, id := range sortedKeys(e.config.Schedules) {
+		s := e.config.Schedules[id]
+
+		w.comment(fmt.Sprintf("Schedule %s: %s (%s)", id, s.Name, s.Status))
+
+		if s.Description != "" {
+			w.comment("  " + s.Description)
+		}
+
+		w.comment(fmt.Sprintf("  at %s: %s %s %s", s.Localtime, s.Command.Method, s.Command.Address, bodyString(s.Command.Body)))
+		w.blank()
+	}
+
+	return w.String()
+}
+
+// bodyKeys are what a body block can set.  Other bodies, e.g. for a CLIP sensor or a schedule, are written as body_json.
+var bodyKeys = map[string]bool{
+	"on": true, "scene": true, "bri": true, "hue": true, "sat": true, "ct": true, "transitiontime": true,
+	"bri_inc": true, "hue_inc": true, "sat_inc": true, "ct_inc": true, "xy_inc": true, "alert": true, "effect": true,
+	"xy": true,
+}
+
+func onlyBodyKeys(body map[string]interface{}) bool {
+	for key := range body {
+		if !bodyKeys[key] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (e *exporter) action(w *writer, block string, a action) {
+	w.open(block)
+	w.attribute("address", e.address(a.Address))
+	w.attribute("method", quote(a.Method))
+
+	if !onlyBodyKeys(a.Body) {
+		// Decoded JSON always encodes again.
+		body, _ := json.Marshal(a.Body)
+
+		w.attribute("body_json", quote(string(body)))
+		w.close()
+
+		return
+	}
+
+	w.open("body")
+
+	for _, key := range sortedKeys(a.Body) {
+		value := a.Body[key]
+
+		switch key {
+		case "on":
+			if on, ok := value.(bool); ok {
+				w.attribute("state", quote(onOff(on)))
+			}
+		case "scene":
+			w.attribute("scene", e.reference(sceneResource, fmt.Sprint(value)))
+		case "bri", "hue", "sat", "ct", "transitiontime", "bri_inc", "hue_inc", "sat_inc", "ct_inc", "xy_inc":
+			w.attribute(key, fmt.Sprint(value))
+		case "alert", "effect":
+			w.attribute(key, quote(fmt.Sprint(value)))
+		case "xy":
+			if xy, ok := value.([]interface{}); ok && len(xy) == 2 {
+				w.attribute("xy", fmt.Sprintf("[%v, %v]", xy[0], xy[1]))
+			}
+		}
+	}
+
+	w.close()
+	w.close()
+}
+
+// open starts a resource block and records the matching import command.
+func (e *exporter) open(w *writer, resourceType string, id string) {
+	name := e.names[resourceType][id]
+
+	w.open(fmt.Sprintf("resource %q %q", resourceType, name))
+	e.imports = append(e.imports, fmt.Sprintf("terraform import %s.%s %s", resourceType, name, id))
+}
+
+// reference returns an interpolation of the id of the exported object, or the literal id if it wasn't exported.
+func (e *exporter) reference(resourceType string, id string) string {
+	if name, ok := e.names[resourceType][id]; ok {
+		return fmt.Sprintf(`"${%s.%s.id}"`, resourceType, name)
+	}
+
+	return quote(id)
+}
+
+func (e *exporter) lightList(ids []string) string {
+	var references []string
+
+	for _, id := range ids {
+		references = append(references, e.reference(lightDataSource, id))
+	}
+
+	return "[ " + strings.Join(references, ", ") + " ]"
+}
+
+var addressPattern = regexp.MustCompile(`^/(lights|sensors|groups|scenes|rules)/([^/]+)(/.*)?$`)
+
+// address rewrites the id in a rule address such as /sensors/8/state/buttonevent to a reference.
+func (e *exporter) address(address string) string {
+	match := addressPattern.FindStringSubmatch(address)
+
+	if match == nil {
+		return quote(address)
+	}
+
+	resourceType := map[string]string{
+		"lights":  lightDataSource,
+		"sensors": sensorDataSource,
+		"groups":  groupResource,
+		"scenes":  sceneResource,
+		"rules":   ruleResource,
+	}[match[1]]
+
+	name, ok := e.names[resourceType][match[2]]
+
+	if !ok {
+		return quote(address)
+	}
+
+	return fmt.Sprintf(`"/%s/${%s.%s.id}%s"`, match[1], resourceType, name, escape(match[3]))
+}
+
+func (e *exporter) assignNames(resourceType string, objectNames map[string]string) {
+	names := make(map[string]string)
+	used := make(map[string]bool)
+
+	for _, id := range sortedKeys(objectNames) {
+		name := terraformName(objectNames[id])
+
+		if used[name] {
+			name = fmt.Sprintf("%s-%s", name, invalidNameCharacters.ReplaceAllString(strings.ToLower(id), "-"))
+		}
+
+		used[name] = true
+		names[id] = name
+	}
+
+	e.names[resourceType] = names
+}
+
+var invalidNameCharacters = regexp.MustCompile(`[^a-z0-9_-]+`)
+
+func terraformName(name string) string {
+	name = strings.Trim(invalidNameCharacters.ReplaceAllString(strings.ToLower(name), "-"), "-")
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "hue-" + name
+	}
+
+	return name
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+
+	return "off"
+}
+
+func floatList(values []float64) string {
+	var formatted []string
+
+	for _, value := range values {
+		formatted = append(formatted, strconv.FormatFloat(value, 'f', -1, 64))
+	}
+
+	return "[" + strings.Join(formatted, ", ") + "]"
+}
+
+func bodyString(body map[string]interface{}) string {
+	var pairs []string
+
+	for _, key := range sortedKeys(body) {
+		pairs = append(pairs, fmt.Sprintf("%s=%v", key, body[key]))
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,105 @@
+package export
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// writer builds HCL one line at a time, indenting nested blocks.
+type writer struct {
+	strings.Builder
+	depth int
+}
+
+func (w *writer) line(text string) {
+	w.WriteString(strings.Repeat("    ", w.depth) + text + "\n")
+}
+
+func (w *writer) open(header string) {
+	w.line(header + " {")
+	w.depth++
+}
+
+func (w *writer) close() {
+	w.depth--
+	w.line("}")
+}
+
+// attribute writes key = value; value must already be valid HCL, e.g. from quote().
+func (w *writer) attribute(key string, value string) {
+	w.line(key + " = " + value)
+}
+
+func (w *writer) comment(text string) {
+	w.line("# " + text)
+}
+
+func (w *writer) blank() {
+	w.WriteString("\n")
+}
+
+// quote returns s as an HCL string literal.
+func quote(s string) string {
+	return `"` + escape(s) + `"`
+}
+
+// templateSequences are doubled so that names like "50%{x}" aren't read as interpolation or a template directive.
+var templateSequences = strings.NewReplacer("${", "$${", "%{", "%%{")
+
+// escape makes s safe to put between quotes in HCL.  Only the escapes HCL understands are used: \n, \r, \t, \", \\
+// and \u or \U for anything else that isn't printable.
+func escape(s string) string {
+	var escaped strings.Builder
+
+	for _, r := range s {
+		switch r {
+		case '"':
+			escaped.WriteString(`\"`)
+		case '\\':
+			escaped.WriteString(`\\`)
+		case '\n':
+			escaped.WriteString(`\n`)
+		case '\r':
+			escaped.WriteString(`\r`)
+		case '\t':
+			escaped.WriteString(`\t`)
+		default:
+			switch {
+			case unicode.IsPrint(r):
+				escaped.WriteRune(r)
+			case r > 0xffff:
+				fmt.Fprintf(&escaped, `\U%08x`, r)
+			default:
+				fmt.Fprintf(&escaped, `\u%04x`, r)
+			}
+		}
+	}
+
+	return templateSequences.Replace(escaped.String())
+}
+
+// sortedKeys returns the keys of a map with string keys, ordering numeric ids numerically so light 10 follows 9.
+func sortedKeys(m interface{}) []string {
+	var keys []string
+
+	for _, key := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, key.String())
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+
+		if errA == nil && errB == nil {
+			return a < b
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	return keys
+}
@@ -0,0 +1,51 @@
+package export
+
+import "testing"
+
+func TestEscape(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{"Living room", "Living room"},
+		{`Say "hi"`, `Say \"hi\"`},
+		{`C:\lights`, `C:\\lights`},
+		{"one\ntwo\r\tthree", `one\ntwo\r\tthree`},
+		{"${var.name}", "$${var.name}"},
+		{"$${var.name}", "$$${var.name}"},
+		{"50%{x}", "50%%{x}"},
+		{"$5 and 50%", "$5 and 50%"},
+		{"bell\a and tab\v", `bell\u0007 and tab\u000b`},
+		{"nul\x00", `nul\u0000`},
+		{"zero\u200bwidth", `zero\u200bwidth`},
+		{"tag\U000e0001", `tag\U000e0001`},
+		{"Küche 🌙", "Küche 🌙"},
+	}
+
+	for _, c := range cases {
+		if got := escape(c.value); got != c.want {
+			t.Errorf("escape(%q) = %q, want %q", c.value, got, c.want)
+		}
+	}
+}
+
+func TestTerraformName(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"Living room", "living-room"},
+		{"Kitchen_Lights", "kitchen_lights"},
+		{"  Bedroom (upstairs)!", "bedroom-upstairs"},
+		{"Küche", "k-che"},
+		{"2nd floor", "hue-2nd-floor"},
+		{"!!!", "hue-"},
+		{"", "hue-"},
+	}
+
+	for _, c := range cases {
+		if got := terraformName(c.name); got != c.want {
+			t.Errorf("terraformName(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+const importByNamePrefix = "name:"
+
+// importByIdOrName returns an importer for the objects listed under path (e.g. "/groups") which accepts either the
+// object's id or "name:<name>".  The resource's Read fills in everything else after import.
+func importByIdOrName(path string) schema.StateFunc {
+	return func(d *schema.ResourceData, m interface{}) ([]*schema.ResourceData, error) {
+		connection := m.(*common.Connection)
+
+		id, err := resolveIdOrName(connection, path, d.Id())
+
+		if err != nil {
+			return nil, err
+		}
+
+		d.SetId(id)
+
+		return []*schema.ResourceData{d}, nil
+	}
+}
+
+func resolveIdOrName(connection *common.Connection, path string, idOrName string) (string, error) {
+	var objects map[string]struct {
+		Name string `json:"name"`
+	}
+
+	err := bridge.Get(connection, path, &objects)
+
+	if err != nil {
+		return "", err
+	}
+
+	if !strings.HasPrefix(idOrName, importByNamePrefix) {
+		if _, ok := objects[idOrName]; !ok {
+			return "", fmt.Errorf("no object with id %q found in %s", idOrName, path)
+		}
+
+		return idOrName, nil
+	}
+
+	name := strings.TrimPrefix(idOrName, importByNamePrefix)
+
+	var matches []string
+
+	for id, object := range objects {
+		if object.Name == name {
+			matches = append(matches, id)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no object named %q found in %s", name, path)
+	case 1:
+		return matches[0], nil
+	default:
+		sort.Strings(matches)
+		return "", fmt.Errorf("%d objects named %q found in %s (ids %s), import by id instead", len(matches), name, path, strings.Join(matches, ", "))
+	}
+}
@@ -0,0 +1,89 @@
+package hue
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestResolveIdOrName(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{
+		"GET /groups": `{"1": {"name": "Kitchen"}, "2": {"name": "Basement"}, "7": {"name": "Hall"}, "8": {"name": "Hall"}}`,
+	})
+	defer bridge.Close()
+
+	cases := []struct {
+		idOrName string
+		want     string
+		wantErr  bool
+	}{
+		{"1", "1", false},
+		{"name:Basement", "2", false},
+		{"3", "", true},
+		{"Kitchen", "", true},
+		{"name:Attic", "", true},
+		{"name:Hall", "", true},
+	}
+
+	for _, c := range cases {
+		got, err := resolveIdOrName(connection, "/groups", c.idOrName)
+
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("resolveIdOrName(%q) = %q, want an error", c.idOrName, got)
+			}
+		} else if err != nil {
+			t.Errorf("resolveIdOrName(%q): %s", c.idOrName, err)
+		} else if got != c.want {
+			t.Errorf("resolveIdOrName(%q) = %q, want %q", c.idOrName, got, c.want)
+		}
+	}
+}
+
+// An imported scene has only its id until it's read, so everything the configuration leaves at its default has to be
+// filled in by then or the first plan isn't clean.
+func TestImportScenePlansClean(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{
+		"GET /scenes": `{"ab12": {"name": "Evening"}, "cd34": {"name": "Morning"}}`,
+		"GET /scenes/ab12": `{"name": "Evening", "type": "LightScene", "lights": ["1", "2"], "owner": "test",
+			"recycle": true, "locked": false, "appdata": {"version": 1, "data": "x"}, "picture": "",
+			"lightstates": {"1": {"on": true, "bri": 200, "ct": 366}, "2": {"on": false}}}`,
+	})
+	defer bridge.Close()
+
+	resource := resourceScene()
+
+	imported, err := resource.Importer.State(resource.Data(&terraform.InstanceState{ID: "name:Evening"}), connection)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(imported) != 1 || imported[0].Id() != "ab12" {
+		t.Fatalf("imported %v, want scene ab12", imported)
+	}
+
+	if err := resourceSceneRead(imported[0], connection); err != nil {
+		t.Fatal(err)
+	}
+
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"name": "Evening",
+		"light_state": []interface{}{
+			map[string]interface{}{"light_id": "1", "state": "on", "bri": 200, "ct": 366},
+			map[string]interface{}{"light_id": "2", "state": "off"},
+		},
+	})
+
+	diff, err := resource.Diff(imported[0].State(), config, connection)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !diff.Empty() {
+		for key, attribute := range diff.Attributes {
+			t.Errorf("plan after import changes %s from %q to %q", key, attribute.Old, attribute.New)
+		}
+	}
+}
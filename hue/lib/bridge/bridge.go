@@ -0,0 +1,185 @@
+package bridge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/constants"
+)
+
+// Error is an error object returned by the bridge, e.g. [{"error": {"type": 3, ...}}]
+type Error struct {
+	Type        int    `json:"type"`
+	Address     string `json:"address"`
+	Description string `json:"description"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("hue error %d on %s: %s", e.Type, e.Address, e.Description)
+}
+
+// IsNotFound reports whether err is the bridge telling us the resource doesn't exist.
+func IsNotFound(err error) bool {
+	hueErr, ok := err.(*Error)
+
+	return ok && hueErr.Type == int(constants.NOT_FOUND)
+}
+
+type response struct {
+	Success map[string]interface{} `json:"success"`
+	Error   *Error                 `json:"error"`
+}
+
+// Get fetches path (e.g. "/lights/1") and decodes the response into result.
+func Get(connection *common.Connection, path string, result interface{}) error {
+	body, err := do(connection, http.MethodGet, path, nil)
+
+	if err != nil {
+		return err
+	}
+
+	// Errors come back as an array, while a successful GET is always an object.
+	if _, err := decodeResponses(body); err != nil {
+		return err
+	}
+
+	return json.Unmarshal(body, result)
+}
+
+// Put sends body to path, failing on the first error the bridge reports.
+func Put(connection *common.Connection, path string, body interface{}) error {
+	responseBody, err := do(connection, http.MethodPut, path, body)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = decodeResponses(responseBody)
+
+	return err
+}
+
+// Post sends body to path and returns the id of the created object.
+func Post(connection *common.Connection, path string, body interface{}) (string, error) {
+	responseBody, err := do(connection, http.MethodPost, path, body)
+
+	if err != nil {
+		return "", err
+	}
+
+	responses, err := decodeResponses(responseBody)
+
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range responses {
+		if id, ok := r.Success["id"].(string); ok {
+			return id, nil
+		}
+	}
+
+	return "", fmt.Errorf("bridge did not return an id for POST %s", path)
+}
+
+// Delete removes the object at path.
+func Delete(connection *common.Connection, path string) error {
+	responseBody, err := do(connection, http.MethodDelete, path, nil)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = decodeResponses(responseBody)
+
+	return err
+}
+
+func do(connection *common.Connection, method string, path string, body interface{}) ([]byte, error) {
+	url := fmt.Sprintf("http://%s/api/%s%s", connection.Host, connection.Username, path)
+
+	var requestBody []byte
+
+	if body != nil {
+		var err error
+		requestBody, err = json.Marshal(body)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if connection.Verbose {
+		logrus.Infof("%s %s %s", method, redact(connection, url), redact(connection, string(requestBody)))
+	}
+
+	request, err := http.NewRequest(method, url, bytes.NewReader(requestBody))
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+
+	httpResponse, err := http.DefaultClient.Do(request)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer httpResponse.Body.Close()
+
+	responseBody, err := ioutil.ReadAll(httpResponse.Body)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if connection.Verbose {
+		logrus.Infof("Response: %s", redact(connection, string(responseBody)))
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s returned HTTP %d", method, path, httpResponse.StatusCode)
+	}
+
+	return responseBody, nil
+}
+
+// redact keeps the username out of what's logged, since it's all it takes to control the bridge.  It's in every URL,
+// and /config lists it in the whitelist.
+func redact(connection *common.Connection, text string) string {
+	if connection.Username == "" {
+		return text
+	}
+
+	return strings.Replace(text, connection.Username, "<username>", -1)
+}
+
+// decodeResponses parses the [{"success": ...}, {"error": ...}] list the bridge returns, and returns the first error in
+// it.  A body which isn't a list is returned as no responses.
+func decodeResponses(body []byte) ([]response, error) {
+	if !strings.HasPrefix(strings.TrimSpace(string(body)), "[") {
+		return nil, nil
+	}
+
+	var responses []response
+
+	if err := json.Unmarshal(body, &responses); err != nil {
+		return nil, err
+	}
+
+	for _, r := range responses {
+		if r.Error != nil {
+			return responses, r.Error
+		}
+	}
+
+	return responses, nil
+}
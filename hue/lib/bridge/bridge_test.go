@@ -0,0 +1,31 @@
+package bridge
+
+import (
+	"testing"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+)
+
+func TestRedact(t *testing.T) {
+	connection := &common.Connection{Host: "192.168.1.2", Username: "s3cr3tUs3rn4m3"}
+
+	cases := []struct {
+		text string
+		want string
+	}{
+		{"http://192.168.1.2/api/s3cr3tUs3rn4m3/lights/1", "http://192.168.1.2/api/<username>/lights/1"},
+		{`{"whitelist":{"s3cr3tUs3rn4m3":{"name":"terraform"}}}`, `{"whitelist":{"<username>":{"name":"terraform"}}}`},
+		{`{"on":true}`, `{"on":true}`},
+		{"", ""},
+	}
+
+	for _, c := range cases {
+		if got := redact(connection, c.text); got != c.want {
+			t.Errorf("redact(%q) = %q, want %q", c.text, got, c.want)
+		}
+	}
+
+	if got := redact(&common.Connection{}, "http://192.168.1.2/api//config"); got != "http://192.168.1.2/api//config" {
+		t.Errorf("redact with no username = %q", got)
+	}
+}
@@ -0,0 +1,70 @@
+package bridge
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+)
+
+// apiVersions caches each bridge's apiversion, since it only changes with a firmware update.
+var apiVersions sync.Map
+
+// APIVersion returns the bridge's API version as reported by /config, e.g. "1.29.0".
+func APIVersion(connection *common.Connection) (string, error) {
+	if version, ok := apiVersions.Load(connection.Host); ok {
+		return version.(string), nil
+	}
+
+	var config struct {
+		APIVersion string `json:"apiversion"`
+	}
+
+	if err := Get(connection, "/config", &config); err != nil {
+		return "", err
+	}
+
+	apiVersions.Store(connection.Host, config.APIVersion)
+
+	return config.APIVersion, nil
+}
+
+// SupportsAPIVersion reports whether the bridge's API is at least minimum, e.g. "1.29.0".
+func SupportsAPIVersion(connection *common.Connection, minimum string) (bool, error) {
+	version, err := APIVersion(connection)
+
+	if err != nil {
+		return false, err
+	}
+
+	return compareVersions(version, minimum) >= 0, nil
+}
+
+// compareVersions compares dotted version numbers, returning -1, 0 or 1.  Missing parts count as 0.
+func compareVersions(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var aPart, bPart int
+
+		if i < len(aParts) {
+			aPart, _ = strconv.Atoi(aParts[i])
+		}
+
+		if i < len(bParts) {
+			bPart, _ = strconv.Atoi(bParts[i])
+		}
+
+		if aPart < bPart {
+			return -1
+		}
+
+		if aPart > bPart {
+			return 1
+		}
+	}
+
+	return 0
+}
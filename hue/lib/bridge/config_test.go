@@ -0,0 +1,25 @@
+package bridge
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"1.22.0", "1.22.0", 0},
+		{"1.22", "1.22.0", 0},
+		{"1.9.0", "1.22.0", -1},
+		{"1.22.0", "1.9.0", 1},
+		{"1.31.0", "1.28.0", 1},
+		{"1.28.0", "1.28.1", -1},
+		{"2.0", "1.50.0", 1},
+		{"", "1.0.0", -1},
+	}
+
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package color converts the colours people write (hex, CSS names and colour temperatures) into the xy coordinates
+// and mireds understood by Hue lights.
+package color
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// RGB is an sRGB colour.
+type RGB struct {
+	R, G, B uint8
+}
+
+// XY is a point in the CIE 1931 colour space.
+type XY struct {
+	X, Y float64
+}
+
+// Gamut is the triangle of xy colours a light can show.
+type Gamut struct {
+	Red, Green, Blue XY
+}
+
+// The gamuts Hue lights report as colorgamuttype.  Gamut A is LivingColors and LightStrips, B is the first
+// generation of bulbs and C is everything since.
+var (
+	GamutA = Gamut{Red: XY{0.704, 0.296}, Green: XY{0.2151, 0.7106}, Blue: XY{0.138, 0.08}}
+	GamutB = Gamut{Red: XY{0.675, 0.322}, Green: XY{0.409, 0.518}, Blue: XY{0.167, 0.04}}
+	GamutC = Gamut{Red: XY{0.6915, 0.3083}, Green: XY{0.17, 0.7}, Blue: XY{0.1532, 0.0475}}
+)
+
+// White is the D65 white point, used for black since it has no chromaticity.
+var White = XY{0.3127, 0.3290}
+
+// GamutFor returns the gamut with the given colorgamuttype.  Anything unknown is treated as gamut C, the widest in
+// practice.
+func GamutFor(gamutType string) Gamut {
+	switch strings.ToUpper(gamutType) {
+	case "A":
+		return GamutA
+	case "B":
+		return GamutB
+	default:
+		return GamutC
+	}
+}
+
+// Parse reads a colour written as #rgb, #rrggbb, rgb(r, g, b) or a CSS colour name.
+func Parse(value string) (RGB, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+
+	if named, ok := cssColors[value]; ok {
+		return named, nil
+	}
+
+	if strings.HasPrefix(value, "#") {
+		return parseHex(value[1:])
+	}
+
+	if strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")") {
+		return parseRGBFunction(value[len("rgb(") : len(value)-1])
+	}
+
+	return RGB{}, fmt.Errorf("%q is not a colour; use #rrggbb, #rgb, rgb(r, g, b) or a CSS colour name", value)
+}
+
+func parseHex(hex string) (RGB, error) {
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+
+	if len(hex) != 6 {
+		return RGB{}, fmt.Errorf("#%s is not a colour; hex colours have 3 or 6 digits", hex)
+	}
+
+	value, err := strconv.ParseUint(hex, 16, 32)
+
+	if err != nil {
+		return RGB{}, fmt.Errorf("#%s is not a hex colour", hex)
+	}
+
+	return RGB{R: uint8(value >> 16), G: uint8(value >> 8), B: uint8(value)}, nil
+}
+
+func parseRGBFunction(arguments string) (RGB, error) {
+	parts := strings.Split(arguments, ",")
+
+	if len(parts) != 3 {
+		return RGB{}, fmt.Errorf("rgb(%s) is not a colour; it needs red, green and blue", arguments)
+	}
+
+	var channels [3]uint8
+
+	for i, part := range parts {
+		channel, err := strconv.ParseUint(strings.TrimSpace(part), 10, 8)
+
+		if err != nil {
+			return RGB{}, fmt.Errorf("rgb(%s) is not a colour; each channel must be 0 to 255", arguments)
+		}
+
+		channels[i] = uint8(channel)
+	}
+
+	return RGB{R: channels[0], G: channels[1], B: channels[2]}, nil
+}
+
+// Hex formats the colour as #rrggbb.
+func (c RGB) Hex() string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// XY converts the colour to the closest xy the gamut can show.  Brightness is lost; it's bri on a light.
+func (c RGB) XY(gamut Gamut) XY {
+	r := toLinear(c.R)
+	g := toLinear(c.G)
+	b := toLinear(c.B)
+
+	// Wide gamut D65 conversion, as recommended by Philips.
+	x := r*0.664511 + g*0.154324 + b*0.162028
+	y := r*0.283881 + g*0.668433 + b*0.047685
+	z := r*0.000088 + g*0.072310 + b*0.986039
+
+	if x+y+z == 0 {
+		return gamut.Clamp(White)
+	}
+
+	return gamut.Clamp(XY{X: x / (x + y + z), Y: y / (x + y + z)})
+}
+
+// FromXY converts xy to the brightest RGB colour with that chromaticity.
+func FromXY(xy XY) RGB {
+	if xy.Y == 0 {
+		return RGB{}
+	}
+
+	x := xy.X / xy.Y
+	z := (1 - xy.X - xy.Y) / xy.Y
+
+	r := x*1.656492 - 0.354851 - z*0.255038
+	g := -x*0.707196 + 1.655397 + z*0.036152
+	b := x*0.051713 - 0.121364 + z*1.011530
+
+	r, g, b = math.Max(r, 0), math.Max(g, 0), math.Max(b, 0)
+
+	if brightest := math.Max(r, math.Max(g, b)); brightest > 0 {
+		r, g, b = r/brightest, g/brightest, b/brightest
+	}
+
+	return RGB{R: fromLinear(r), G: fromLinear(g), B: fromLinear(b)}
+}
+
+func toLinear(channel uint8) float64 {
+	value := float64(channel) / 255
+
+	if value > 0.04045 {
+		return math.Pow((value+0.055)/1.055, 2.4)
+	}
+
+	return value / 12.92
+}
+
+func fromLinear(value float64) uint8 {
+	if value <= 0.0031308 {
+		value = value * 12.92
+	} else {
+		value = 1.055*math.Pow(value, 1/2.4) - 0.055
+	}
+
+	return uint8(math.Round(math.Min(math.Max(value, 0), 1) * 255))
+}
+
+// Contains reports whether the gamut can show xy.
+func (g Gamut) Contains(xy XY) bool {
+	d1 := cross(g.Red, g.Green, xy)
+	d2 := cross(g.Green, g.Blue, xy)
+	d3 := cross(g.Blue, g.Red, xy)
+
+	hasNegative := d1 < 0 || d2 < 0 || d3 < 0
+	hasPositive := d1 > 0 || d2 > 0 || d3 > 0
+
+	return !(hasNegative && hasPositive)
+}
+
+// Clamp returns xy if the gamut can show it, otherwise the nearest point on the edge of the gamut.
+func (g Gamut) Clamp(xy XY) XY {
+	if g.Contains(xy) {
+		return xy
+	}
+
+	closest := closestOnLine(g.Red, g.Green, xy)
+
+	for _, point := range []XY{closestOnLine(g.Green, g.Blue, xy), closestOnLine(g.Blue, g.Red, xy)} {
+		if distance(point, xy) < distance(closest, xy) {
+			closest = point
+		}
+	}
+
+	return closest
+}
+
+func cross(a XY, b XY, p XY) float64 {
+	return (b.X-a.X)*(p.Y-a.Y) - (b.Y-a.Y)*(p.X-a.X)
+}
+
+func closestOnLine(a XY, b XY, p XY) XY {
+	dx, dy := b.X-a.X, b.Y-a.Y
+
+	t := ((p.X-a.X)*dx + (p.Y-a.Y)*dy) / (dx*dx + dy*dy)
+	t = math.Min(math.Max(t, 0), 1)
+
+	return XY{X: a.X + t*dx, Y: a.Y + t*dy}
+}
+
+func distance(a XY, b XY) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
@@ -0,0 +1,133 @@
+package color
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		value string
+		want  RGB
+	}{
+		{"#ff8800", RGB{255, 136, 0}},
+		{"#f80", RGB{255, 136, 0}},
+		{"#FF8800", RGB{255, 136, 0}},
+		{" #ff8800 ", RGB{255, 136, 0}},
+		{"rgb(255, 136, 0)", RGB{255, 136, 0}},
+		{"rgb(255,136,0)", RGB{255, 136, 0}},
+		{"tomato", RGB{255, 99, 71}},
+		{"Tomato", RGB{255, 99, 71}},
+		{"black", RGB{0, 0, 0}},
+	}
+
+	for _, c := range cases {
+		got, err := Parse(c.value)
+
+		if err != nil {
+			t.Errorf("Parse(%q): %s", c.value, err)
+		} else if got != c.want {
+			t.Errorf("Parse(%q) = %v, want %v", c.value, got, c.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, value := range []string{"", "ff8800", "#ff88", "#gggggg", "rgb(256, 0, 0)", "rgb(1, 2)", "rgb(1, 2, 3", "not-a-colour"} {
+		if got, err := Parse(value); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", value, got)
+		}
+	}
+}
+
+func TestRGBXY(t *testing.T) {
+	cases := []struct {
+		rgb   RGB
+		gamut Gamut
+		want  XY
+	}{
+		{RGB{255, 255, 255}, GamutC, XY{0.3227, 0.3290}},
+		{RGB{0, 0, 0}, GamutC, White},
+		{RGB{255, 0, 0}, GamutC, XY{0.6915, 0.3083}},
+		{RGB{255, 0, 0}, GamutB, XY{0.6750, 0.3220}},
+	}
+
+	for _, c := range cases {
+		got := c.rgb.XY(c.gamut)
+
+		if !near(got, c.want, 0.0005) {
+			t.Errorf("%v.XY(%v) = %v, want %v", c.rgb, c.gamut, got, c.want)
+		}
+
+		if !c.gamut.Contains(got) && !near(got, c.gamut.Clamp(got), 1e-9) {
+			t.Errorf("%v.XY(%v) = %v, which is outside the gamut", c.rgb, c.gamut, got)
+		}
+	}
+}
+
+func TestGamutClamp(t *testing.T) {
+	cases := []struct {
+		xy    XY
+		gamut Gamut
+		want  XY
+	}{
+		{White, GamutC, White},
+		{XY{0.6, 0.3}, GamutC, XY{0.6, 0.3}},
+		{XY{0.9, 0.3}, GamutC, GamutC.Red},
+		{XY{0.1, 0.9}, GamutA, GamutA.Green},
+		{XY{0.1, 0.0}, GamutB, GamutB.Blue},
+		{XY{0.5, 0.5}, GamutB, XY{0.4766, 0.4682}},
+	}
+
+	for _, c := range cases {
+		if got := c.gamut.Clamp(c.xy); !near(got, c.want, 0.0005) {
+			t.Errorf("%v.Clamp(%v) = %v, want %v", c.gamut, c.xy, got, c.want)
+		}
+	}
+}
+
+func TestHSBRoundTrip(t *testing.T) {
+	for _, rgb := range []RGB{{255, 0, 0}, {0, 255, 0}, {0, 0, 255}, {255, 136, 0}, {255, 99, 71}, {18, 52, 86}, {255, 255, 255}, {128, 128, 128}} {
+		got := rgb.HSB().RGB()
+
+		if channelDistance(got.R, rgb.R) > 1 || channelDistance(got.G, rgb.G) > 1 || channelDistance(got.B, rgb.B) > 1 {
+			t.Errorf("%v.HSB().RGB() = %v", rgb, got)
+		}
+	}
+}
+
+func TestKelvinToXY(t *testing.T) {
+	cases := []struct {
+		kelvin int
+		want   XY
+	}{
+		{2000, XY{0.5266, 0.4133}},
+		{2700, XY{0.4593, 0.4107}},
+		{4000, XY{0.3805, 0.3768}},
+		{6500, XY{0.3135, 0.3237}},
+	}
+
+	for _, c := range cases {
+		got := KelvinToXY(c.kelvin)
+
+		if !near(got, c.want, 0.0005) {
+			t.Errorf("KelvinToXY(%d) = %v, want %v", c.kelvin, got, c.want)
+		}
+
+		if kelvin := XYToKelvin(got); math.Abs(float64(kelvin-c.kelvin)) > 50 {
+			t.Errorf("XYToKelvin(KelvinToXY(%d)) = %d", c.kelvin, kelvin)
+		}
+	}
+}
+
+func near(a XY, b XY, tolerance float64) bool {
+	return math.Abs(a.X-b.X) <= tolerance && math.Abs(a.Y-b.Y) <= tolerance
+}
+
+func channelDistance(a uint8, b uint8) int {
+	if a > b {
+		return int(a - b)
+	}
+
+	return int(b - a)
+}
@@ -0,0 +1,88 @@
+package color
+
+import "math"
+
+// HSB is a colour in the units of a light's hue (0 to 65535), sat (0 to 254) and bri (1 to 254).
+type HSB struct {
+	Hue, Sat, Bri int
+}
+
+// HSB converts the colour to hue, saturation and brightness.  Black has the lowest brightness a light has, 1.
+func (c RGB) HSB() HSB {
+	r, g, b := float64(c.R)/255, float64(c.G)/255, float64(c.B)/255
+
+	max := math.Max(r, math.Max(g, b))
+	min := math.Min(r, math.Min(g, b))
+	delta := max - min
+
+	var hue float64
+
+	switch {
+	case delta == 0:
+		hue = 0
+	case max == r:
+		hue = math.Mod((g-b)/delta, 6)
+	case max == g:
+		hue = (b-r)/delta + 2
+	default:
+		hue = (r-g)/delta + 4
+	}
+
+	if hue < 0 {
+		hue += 6
+	}
+
+	var sat float64
+
+	if max > 0 {
+		sat = delta / max
+	}
+
+	return HSB{
+		Hue: int(math.Round(hue/6*65535)) % 65536,
+		Sat: int(math.Round(sat * 254)),
+		Bri: int(math.Max(math.Round(max*254), 1)),
+	}
+}
+
+// RGB converts hue, saturation and brightness to a colour.
+func (c HSB) RGB() RGB {
+	hue := float64(c.Hue) / 65536 * 6
+	sat := float64(c.Sat) / 254
+	bri := float64(c.Bri) / 254
+
+	chroma := bri * sat
+	x := chroma * (1 - math.Abs(math.Mod(hue, 2)-1))
+	m := bri - chroma
+
+	var r, g, b float64
+
+	switch int(hue) {
+	case 0:
+		r, g, b = chroma, x, 0
+	case 1:
+		r, g, b = x, chroma, 0
+	case 2:
+		r, g, b = 0, chroma, x
+	case 3:
+		r, g, b = 0, x, chroma
+	case 4:
+		r, g, b = x, 0, chroma
+	default:
+		r, g, b = chroma, 0, x
+	}
+
+	return RGB{R: toChannel(r + m), G: toChannel(g + m), B: toChannel(b + m)}
+}
+
+func toChannel(value float64) uint8 {
+	return uint8(math.Round(math.Min(math.Max(value, 0), 1) * 255))
+}
+
+// WithBri returns the colour at the given brightness, keeping its hue and saturation.
+func (c RGB) WithBri(bri int) RGB {
+	hsb := c.HSB()
+	hsb.Bri = bri
+
+	return hsb.RGB()
+}
@@ -0,0 +1,53 @@
+package color
+
+import "math"
+
+// KelvinToMireds converts a colour temperature to mireds, the unit of a light's ct.
+func KelvinToMireds(kelvin int) int {
+	return int(math.Round(1000000 / float64(kelvin)))
+}
+
+// MiredsToKelvin converts a light's ct to a colour temperature.
+func MiredsToKelvin(mireds int) int {
+	return int(math.Round(1000000 / float64(mireds)))
+}
+
+// KelvinToXY returns the colour of a black body at the temperature, using Kim et al.'s approximation of the Planckian
+// locus.  It's accurate from 1667K to 25000K, which covers every temperature a light can show.
+func KelvinToXY(kelvin int) XY {
+	t := float64(kelvin)
+	t2 := t * t
+	t3 := t2 * t
+
+	var x float64
+
+	if t <= 4000 {
+		x = -0.2661239e9/t3 - 0.2343589e6/t2 + 0.8776956e3/t + 0.179910
+	} else {
+		x = -3.0258469e9/t3 + 2.1070379e6/t2 + 0.2226347e3/t + 0.240390
+	}
+
+	x2 := x * x
+	x3 := x2 * x
+
+	var y float64
+
+	switch {
+	case t <= 2222:
+		y = -1.1063814*x3 - 1.34811020*x2 + 2.18555832*x - 0.20219683
+	case t <= 4000:
+		y = -0.9549476*x3 - 1.37418593*x2 + 2.09137015*x - 0.16748867
+	default:
+		y = 3.0817580*x3 - 5.87338670*x2 + 3.75112997*x - 0.37001483
+	}
+
+	return XY{X: x, Y: y}
+}
+
+// XYToKelvin returns the correlated colour temperature of xy using McCamy's approximation.  It's only meaningful for
+// colours near white.
+func XYToKelvin(xy XY) int {
+	n := (xy.X - 0.3320) / (0.1858 - xy.Y)
+
+	return int(math.Round(449*n*n*n + 3525*n*n + 6823.3*n + 5520.33))
+}
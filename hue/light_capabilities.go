@@ -0,0 +1,167 @@
+package hue
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+// lightCapabilities is the part of GET /lights/<id> describing what a light can do.
+type lightCapabilities struct {
+	Name         string `json:"name"`
+	Type         string `json:"type"`
+	Capabilities struct {
+		Control struct {
+			ColorGamutType string       `json:"colorgamuttype"`
+			ColorGamut     [][2]float64 `json:"colorgamut"`
+			CT             *struct {
+				Min int `json:"min"`
+				Max int `json:"max"`
+			} `json:"ct"`
+		} `json:"control"`
+	} `json:"capabilities"`
+}
+
+// What a light needs to be able to do to use an attribute.
+const (
+	capabilityBrightness  = "brightness"
+	capabilityColor       = "colour"
+	capabilityTemperature = "colour temperature"
+)
+
+// lightAttributeCapabilities are the light state and action body attributes which only some lights can use.
+var lightAttributeCapabilities = map[string]string{
+	"bri":                capabilityBrightness,
+	"brightness_percent": capabilityBrightness,
+	"bri_inc":            capabilityBrightness,
+	"hue":                capabilityColor,
+	"sat":                capabilityColor,
+	"xy":                 capabilityColor,
+	"color":              capabilityColor,
+	"hue_inc":            capabilityColor,
+	"sat_inc":            capabilityColor,
+	"xy_inc":             capabilityColor,
+	"effect":             capabilityColor,
+	"ct":                 capabilityTemperature,
+	"kelvin":             capabilityTemperature,
+	"ct_inc":             capabilityTemperature,
+}
+
+// lightStateAddress matches rule actions which set a single light's state.
+var lightStateAddress = regexp.MustCompile(`^/lights/([^/]+)/state$`)
+
+func readLightCapabilities(connection *common.Connection, lightId string) (*lightCapabilities, error) {
+	var light lightCapabilities
+
+	if err := bridge.Get(connection, "/lights/"+lightId, &light); err != nil {
+		return nil, fmt.Errorf("could not read the capabilities of light %s: %s", lightId, err)
+	}
+
+	return &light, nil
+}
+
+// actionLightCapabilities returns the capabilities of the light a rule action addresses.  Anything else, like a group,
+// can hold lights of every gamut, so the bridge is left to fit the colour to each one.
+func actionLightCapabilities(connection *common.Connection, address string) (*lightCapabilities, error) {
+	if match := lightStateAddress.FindStringSubmatch(address); match != nil {
+		return readLightCapabilities(connection, match[1])
+	}
+
+	return &lightCapabilities{}, nil
+}
+
+// supports reports whether the light can use a capability.  Bridges older than API 1.22 don't list capabilities, so the
+// light's type is used as well.
+func (light *lightCapabilities) supports(capability string) bool {
+	control := light.Capabilities.Control
+
+	switch capability {
+	case capabilityColor:
+		return control.ColorGamutType != "" || len(control.ColorGamut) > 0 ||
+			light.Type == "Color light" || light.Type == "Extended color light"
+	case capabilityTemperature:
+		return control.CT != nil || light.Type == "Color temperature light" || light.Type == "Extended color light"
+	case capabilityBrightness:
+		return !strings.EqualFold(light.Type, "On/Off plug-in unit") && !strings.EqualFold(light.Type, "On/off light")
+	}
+
+	return true
+}
+
+// checkAttributes returns an error naming the first of attributes that the light can't use.
+func (light *lightCapabilities) checkAttributes(lightId string, attributes []string) error {
+	sort.Strings(attributes)
+
+	for _, attribute := range attributes {
+		capability, ok := lightAttributeCapabilities[attribute]
+
+		if ok && !light.supports(capability) {
+			return fmt.Errorf("light %s (%s) is a %s, which has no %s, so it can't use %s",
+				lightId, light.Name, light.Type, capability, attribute)
+		}
+	}
+
+	return nil
+}
+
+// setAttributes lists the attributes of a light state or action body which have a value.  hue, sat and transitiontime
+// can be 0, but no other integer can.
+func setAttributes(values map[string]interface{}) []string {
+	var attributes []string
+
+	for key, value := range values {
+		switch value := value.(type) {
+		case string:
+			if value == "" {
+				continue
+			}
+		case int:
+			// Increments can go down as well as up, so only 0 leaves them unset.
+			if strings.HasSuffix(key, "_inc") {
+				if value == 0 {
+					continue
+				}
+			} else if valueRange, ok := lightStateIntRanges[key]; value < 0 || (value == 0 && (!ok || valueRange[0] > 0)) {
+				continue
+			}
+		case float64:
+			if value == 0 {
+				continue
+			}
+		case []interface{}:
+			if len(value) == 0 {
+				continue
+			}
+		case nil:
+			continue
+		}
+
+		attributes = append(attributes, key)
+	}
+
+	return attributes
+}
+
+// validateLightStateCapabilities checks that each light can show what its light state asks for.
+func validateLightStateCapabilities(connection *common.Connection, lightStates []interface{}) error {
+	for _, lightState := range lightStates {
+		lightState := lightState.(map[string]interface{})
+		lightId := lightState["light_id"].(string)
+
+		light, err := readLightCapabilities(connection, lightId)
+
+		if err != nil {
+			return err
+		}
+
+		if err := light.checkAttributes(lightId, setAttributes(lightState)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,155 @@
+package hue
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/color"
+)
+
+// A converted colour read back from the bridge within this of what was stored is still that colour.
+const (
+	colorXYTolerance = 0.001
+	colorCTTolerance = 1
+)
+
+// gamut is the triangle of colours the light can show.  Lights which don't say are treated as gamut C.
+func (light *lightCapabilities) gamut() color.Gamut {
+	control := light.Capabilities.Control
+
+	if len(control.ColorGamut) == 3 {
+		return color.Gamut{
+			Red:   color.XY{X: control.ColorGamut[0][0], Y: control.ColorGamut[0][1]},
+			Green: color.XY{X: control.ColorGamut[1][0], Y: control.ColorGamut[1][1]},
+			Blue:  color.XY{X: control.ColorGamut[2][0], Y: control.ColorGamut[2][1]},
+		}
+	}
+
+	return color.GamutFor(control.ColorGamutType)
+}
+
+// colorXY converts a colour to the xy the light will store, rounded as the bridge does.
+func (light *lightCapabilities) colorXY(value string) (*[2]float64, error) {
+	rgb, err := color.Parse(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	xy := rgb.XY(light.gamut())
+
+	return &[2]float64{roundXY(xy.X), roundXY(xy.Y)}, nil
+}
+
+// kelvinCT converts a colour temperature to the ct the light will store, within the range it supports.
+func (light *lightCapabilities) kelvinCT(kelvin int) int {
+	return light.clampCT(color.KelvinToMireds(kelvin))
+}
+
+// clampCT snaps ct into the range the light supports, as the bridge does when it's stored.
+func (light *lightCapabilities) clampCT(ct int) int {
+	ctRange := lightStateIntRanges["ct"]
+
+	if control := light.Capabilities.Control; control.CT != nil && control.CT.Max > 0 {
+		ctRange = [2]int{control.CT.Min, control.CT.Max}
+	}
+
+	return clampInt(ct, ctRange[0], ctRange[1])
+}
+
+// fitXY moves xy into the light's gamut and rounds it, as the bridge does when it's stored.
+func (light *lightCapabilities) fitXY(xy [2]float64) [2]float64 {
+	fitted := light.gamut().Clamp(color.XY{X: xy[0], Y: xy[1]})
+
+	return [2]float64{roundXY(fitted.X), roundXY(fitted.Y)}
+}
+
+// resolveLightStateColor returns a copy of lightState with stored_xy and stored_ct converted from color and kelvin
+// for the light, which is only read if one of them is set.
+func resolveLightStateColor(connection *common.Connection, lightId string, lightState map[string]interface{}) (map[string]interface{}, error) {
+	resolved := make(map[string]interface{})
+
+	for key, value := range lightState {
+		resolved[key] = value
+	}
+
+	colorValue, _ := lightState["color"].(string)
+	kelvin, _ := lightState["kelvin"].(int)
+
+	if colorValue == "" && kelvin == 0 {
+		return resolved, nil
+	}
+
+	light, err := readLightCapabilities(connection, lightId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if colorValue != "" {
+		xy, err := light.colorXY(colorValue)
+
+		if err != nil {
+			return nil, fmt.Errorf("light %s: %s", lightId, err)
+		}
+
+		resolved["stored_xy"] = []interface{}{xy[0], xy[1]}
+	}
+
+	if kelvin != 0 {
+		resolved["stored_ct"] = light.kelvinCT(kelvin)
+	}
+
+	return resolved, nil
+}
+
+// carryColorForward puts color and kelvin back into a light state read from the bridge when what's stored is still
+// what they were converted to, so configurations written with them don't show a diff.
+func carryColorForward(state map[string]interface{}, previous map[string]interface{}) {
+	if colorValue, _ := previous["color"].(string); colorValue != "" {
+		storedXY := expandXY(previous["stored_xy"])
+		xy := expandXY(state["xy"])
+
+		if storedXY != nil && xy != nil && xyWithinTolerance(*storedXY, *xy, colorXYTolerance) {
+			state["color"] = colorValue
+			state["stored_xy"] = state["xy"]
+			delete(state, "xy")
+		}
+	}
+
+	if kelvin, _ := previous["kelvin"].(int); kelvin != 0 {
+		storedCT, _ := previous["stored_ct"].(int)
+		ct, ok := state["ct"].(int)
+
+		if ok && withinTolerance(storedCT, ct, colorCTTolerance) {
+			state["kelvin"] = kelvin
+			state["stored_ct"] = ct
+			delete(state, "ct")
+		}
+	}
+}
+
+func xyWithinTolerance(configured [2]float64, actual [2]float64, tolerance float64) bool {
+	return math.Abs(configured[0]-actual[0]) <= tolerance && math.Abs(configured[1]-actual[1]) <= tolerance
+}
+
+func validateColor(i interface{}, s string) (_ []string, errors []error) {
+	if _, err := color.Parse(i.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q: %s", s, err))
+	}
+
+	return
+}
+
+func clampInt(value int, min int, max int) int {
+	if value < min {
+		return min
+	}
+
+	if value > max {
+		return max
+	}
+
+	return value
+}
@@ -0,0 +1,99 @@
+package hue
+
+import (
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/color"
+)
+
+// The bridge works out hue and sat itself when it stores a light state in a different colour mode, so they only come
+// back close to what was sent.  Converted to xy, they're further out again since its conversion isn't quite ours.
+const (
+	normalisedHueTolerance = 100
+	normalisedSatTolerance = 2
+	hueSatXYTolerance      = 0.01
+)
+
+// carryNormalisedForward puts configured values back into a light state read from the bridge when what it has is only
+// the bridge's normalisation of them: ct snapped to the light's range, xy rounded and moved into its gamut, and hue and
+// sat recomputed or stored as xy.  The light is only read if something differs.
+func carryNormalisedForward(connection *common.Connection, lightId string, state map[string]interface{}, previous map[string]interface{}) error {
+	var capabilities *lightCapabilities
+
+	readLight := func() (*lightCapabilities, error) {
+		if capabilities != nil {
+			return capabilities, nil
+		}
+
+		var err error
+		capabilities, err = readLightCapabilities(connection, lightId)
+
+		return capabilities, err
+	}
+
+	if configured, _ := previous["ct"].(int); configured > 0 {
+		if actual, ok := state["ct"].(int); ok && actual != configured {
+			light, err := readLight()
+
+			if err != nil {
+				return err
+			}
+
+			if withinTolerance(light.clampCT(configured), actual, colorCTTolerance) {
+				state["ct"] = configured
+			}
+		}
+	}
+
+	if configured := expandXY(previous["xy"]); configured != nil {
+		if actual := expandXY(state["xy"]); actual != nil && *actual != *configured {
+			light, err := readLight()
+
+			if err != nil {
+				return err
+			}
+
+			if xyWithinTolerance(light.fitXY(*configured), *actual, colorXYTolerance) {
+				state["xy"] = previous["xy"]
+			}
+		}
+	}
+
+	configuredHue := expandLightStateInt(previous["hue"])
+	configuredSat := expandLightStateInt(previous["sat"])
+
+	if configuredHue == nil || configuredSat == nil {
+		return nil
+	}
+
+	actualHue := expandLightStateInt(state["hue"])
+	actualSat := expandLightStateInt(state["sat"])
+
+	if actualHue != nil && actualSat != nil {
+		if hueWithinTolerance(*configuredHue, *actualHue, normalisedHueTolerance) &&
+			withinTolerance(*configuredSat, *actualSat, normalisedSatTolerance) {
+			state["hue"] = *configuredHue
+			state["sat"] = *configuredSat
+		}
+
+		return nil
+	}
+
+	// Colour lights in a scene keep hue and sat as xy.
+	if actual := expandXY(state["xy"]); actual != nil && actualHue == nil && actualSat == nil {
+		light, err := readLight()
+
+		if err != nil {
+			return err
+		}
+
+		xy := color.HSB{Hue: *configuredHue, Sat: *configuredSat, Bri: 254}.RGB().XY(light.gamut())
+
+		if xyWithinTolerance([2]float64{xy.X, xy.Y}, *actual, hueSatXYTolerance) {
+			state["hue"] = *configuredHue
+			state["sat"] = *configuredSat
+			delete(state, "xy")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package hue
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/color"
+)
+
+func TestCarryNormalisedForward(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{
+		"GET /lights/1": `{"name": "Hall", "type": "Extended color light", "capabilities": {"control": {
+			"colorgamuttype": "C", "colorgamut": [[0.6915, 0.3083], [0.17, 0.7], [0.1532, 0.0475]],
+			"ct": {"min": 153, "max": 454}}}}`,
+	})
+	defer bridge.Close()
+
+	light := &lightCapabilities{}
+	light.Capabilities.Control.ColorGamut = [][2]float64{{0.6915, 0.3083}, {0.17, 0.7}, {0.1532, 0.0475}}
+	storedXY := color.HSB{Hue: 8000, Sat: 200, Bri: 254}.RGB().XY(light.gamut())
+
+	cases := []struct {
+		name     string
+		previous map[string]interface{}
+		state    map[string]interface{}
+		want     map[string]interface{}
+	}{
+		{
+			name:     "ct snapped to the light's range",
+			previous: map[string]interface{}{"ct": 500},
+			state:    map[string]interface{}{"ct": 454},
+			want:     map[string]interface{}{"ct": 500},
+		},
+		{
+			name:     "ct changed on the bridge",
+			previous: map[string]interface{}{"ct": 300},
+			state:    map[string]interface{}{"ct": 350},
+			want:     map[string]interface{}{"ct": 350},
+		},
+		{
+			name:     "xy rounded",
+			previous: map[string]interface{}{"xy": []interface{}{0.31234, 0.32926}},
+			state:    map[string]interface{}{"xy": []interface{}{0.3123, 0.3293}},
+			want:     map[string]interface{}{"xy": []interface{}{0.31234, 0.32926}},
+		},
+		{
+			name:     "xy moved into the gamut",
+			previous: map[string]interface{}{"xy": []interface{}{0.72, 0.28}},
+			state:    map[string]interface{}{"xy": []interface{}{light.fitXY([2]float64{0.72, 0.28})[0], light.fitXY([2]float64{0.72, 0.28})[1]}},
+			want:     map[string]interface{}{"xy": []interface{}{0.72, 0.28}},
+		},
+		{
+			name:     "xy changed on the bridge",
+			previous: map[string]interface{}{"xy": []interface{}{0.3, 0.3}},
+			state:    map[string]interface{}{"xy": []interface{}{0.5, 0.4}},
+			want:     map[string]interface{}{"xy": []interface{}{0.5, 0.4}},
+		},
+		{
+			name:     "hue and sat recomputed",
+			previous: map[string]interface{}{"hue": 8000, "sat": 200},
+			state:    map[string]interface{}{"hue": 8050, "sat": 201},
+			want:     map[string]interface{}{"hue": 8000, "sat": 200},
+		},
+		{
+			name:     "hue and sat changed on the bridge",
+			previous: map[string]interface{}{"hue": 8000, "sat": 200},
+			state:    map[string]interface{}{"hue": 20000, "sat": 200},
+			want:     map[string]interface{}{"hue": 20000, "sat": 200},
+		},
+		{
+			name:     "hue and sat stored as xy",
+			previous: map[string]interface{}{"hue": 8000, "sat": 200},
+			state:    map[string]interface{}{"xy": []interface{}{roundXY(storedXY.X), roundXY(storedXY.Y)}},
+			want:     map[string]interface{}{"hue": 8000, "sat": 200},
+		},
+		{
+			name:     "hue and sat not set",
+			previous: map[string]interface{}{"hue": unsetLightStateInt, "sat": unsetLightStateInt},
+			state:    map[string]interface{}{"xy": []interface{}{0.5, 0.4}},
+			want:     map[string]interface{}{"xy": []interface{}{0.5, 0.4}},
+		},
+	}
+
+	for _, c := range cases {
+		if err := carryNormalisedForward(connection, "1", c.state, c.previous); err != nil {
+			t.Errorf("%s: %s", c.name, err)
+			continue
+		}
+
+		if fmt.Sprint(c.state) != fmt.Sprint(c.want) {
+			t.Errorf("%s: state = %v, want %v", c.name, c.state, c.want)
+		}
+	}
+}
+
+func TestCarryNormalisedForwardOnlyReadsLightOnDifference(t *testing.T) {
+	// The light isn't on the bridge, so reading it would fail.
+	bridge, connection := newTestBridge(map[string]string{})
+	defer bridge.Close()
+
+	previous := map[string]interface{}{"ct": 366, "hue": unsetLightStateInt, "sat": unsetLightStateInt}
+	state := map[string]interface{}{"ct": 366}
+
+	if err := carryNormalisedForward(connection, "1", state, previous); err != nil {
+		t.Fatal(err)
+	}
+}
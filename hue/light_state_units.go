@@ -0,0 +1,91 @@
+package hue
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// transitiontime is in multiples of 100ms.
+const transitionUnit = 100 * time.Millisecond
+
+// briFromPercent converts brightness_percent (1 to 100) to bri (1 to 254).
+func briFromPercent(percent int) int {
+	return clampInt(int(math.Round(float64(percent)*254/100)), 1, 254)
+}
+
+// percentFromBri is the reverse of briFromPercent, which it round trips with.
+func percentFromBri(bri int) int {
+	return clampInt(int(math.Round(float64(bri)*100/254)), 1, 100)
+}
+
+// parseTransition converts a duration like "1.5s" to a transitiontime.
+func parseTransition(value string) (int, error) {
+	duration, err := time.ParseDuration(value)
+
+	if err != nil {
+		return 0, fmt.Errorf("%q is not a duration like \"400ms\" or \"1.5s\"", value)
+	}
+
+	if duration < 0 {
+		return 0, fmt.Errorf("%q is negative", value)
+	}
+
+	return int(math.Round(float64(duration) / float64(transitionUnit))), nil
+}
+
+// formatTransition is the reverse of parseTransition.
+func formatTransition(transitionTime int) string {
+	return (time.Duration(transitionTime) * transitionUnit).String()
+}
+
+func validateTransition(i interface{}, s string) (_ []string, errors []error) {
+	if _, err := parseTransition(i.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q: %s", s, err))
+	}
+
+	return
+}
+
+// suppressEquivalentTransition ignores a transition written differently but with the same transitiontime, e.g. "1500ms"
+// and "1.5s".
+func suppressEquivalentTransition(k, old, new string, d *schema.ResourceData) bool {
+	oldTransition, err := parseTransition(old)
+
+	if err != nil {
+		return false
+	}
+
+	newTransition, err := parseTransition(new)
+
+	if err != nil {
+		return false
+	}
+
+	return oldTransition == newTransition
+}
+
+// carryUnitsForward reads bri and transitiontime back as brightness_percent and transition in a light state that was
+// written with them.  An unchanged transition keeps the way it was written.
+func carryUnitsForward(state map[string]interface{}, previous map[string]interface{}) {
+	if percent, _ := previous["brightness_percent"].(int); percent != 0 {
+		if bri, ok := state["bri"].(int); ok {
+			state["brightness_percent"] = percentFromBri(bri)
+			delete(state, "bri")
+		}
+	}
+
+	if transition, _ := previous["transition"].(string); transition != "" {
+		if transitionTime := expandLightStateInt(state["transitiontime"]); transitionTime != nil {
+			state["transition"] = formatTransition(*transitionTime)
+
+			if previousTransitionTime, err := parseTransition(transition); err == nil && previousTransitionTime == *transitionTime {
+				state["transition"] = transition
+			}
+
+			state["transitiontime"] = unsetLightStateInt
+		}
+	}
+}
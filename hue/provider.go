@@ -16,7 +16,8 @@ func Provider() *schema.Provider {
 			"hub_username": {
 				Type: schema.TypeString,
 				Required: true,
-				Description: "Username on your Hub.  See Hue API for details on how to create this.",
+				Description: "Username on your Hub.  See Hue API for details on how to create this.  Scenes and rules " +
+					"created by the provider are owned by this user, so it's best to create one just for Terraform.",
 			},
 			"verbose": {
 				Type: schema.TypeBool,
@@ -29,11 +30,18 @@ func Provider() *schema.Provider {
 			"philips-hue_scene": resourceScene(),
 			"philips-hue_group": resourceGroup(),
 			"philips-hue_rule": resourceRule(),
+			"philips-hue_light_state": resourceLightState(),
+			"philips-hue_scene_recall": resourceSceneRecall(),
+			"philips-hue_scene_template": resourceSceneTemplate(),
 		},
 
 		DataSourcesMap: map[string]*schema.Resource {
 			"philips-hue_light": dataSourceHueLight(),
 			"philips-hue_sensor": dataSourceHueSensor(),
+			"philips-hue_light_states": dataSourceHueLightStates(),
+			"philips-hue_unmanaged": dataSourceHueUnmanaged(),
+			"philips-hue_color": dataSourceHueColor(),
+			"philips-hue_scene": dataSourceHueScene(),
 		},
 
 		ConfigureFunc: providerConfigure,
@@ -0,0 +1,9 @@
+package hue
+
+import "testing"
+
+func TestProvider(t *testing.T) {
+	if err := Provider().InternalValidate(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,10 @@
 package hue
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/Sirupsen/logrus"
 	"github.com/hashicorp/terraform/helper/schema"
 	"github.com/lawsontyler/ghue/sdk/groups"
 	"github.com/lawsontyler/ghue/sdk/common"
@@ -15,6 +19,14 @@ func resourceGroup() *schema.Resource {
 		Update: resourceGroupUpdate,
 		Delete: resourceGroupDelete,
 
+		Importer: &schema.ResourceImporter{
+			State: importByIdOrName("/groups"),
+		},
+
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -31,6 +43,7 @@ func resourceGroup() *schema.Resource {
 				Optional: true,
 				Default:  "LightGroup",
 			},
+			"wait_for_dependents": waitForDependentsSchema(),
 		},
 	}
 }
@@ -74,13 +87,33 @@ func resourceGroupRead(d *schema.ResourceData, m interface{}) error {
 
 	group, hueErr, err := groups.GetGroup(connection, d.Id())
 
-	if err != nil && hueErr != nil && hueErr.Error.Type == int(constants.NOT_FOUND) {
+	// Only a group the bridge says is gone is dropped from state; anything else (e.g. an unreachable bridge) has to
+	// fail the refresh rather than look like an empty group.
+	if hueErr != nil && hueErr.Error.Type == int(constants.NOT_FOUND) {
+		logrus.Warnf("Group %s not found on the bridge, removing from state", d.Id())
 		d.SetId("")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if hueErr != nil {
+		return fmt.Errorf("bridge returned error %d reading group %s", hueErr.Error.Type, d.Id())
+	}
+
+	if err := d.Set("name", group.Name); err != nil {
+		return err
+	}
+
+	if err := d.Set("lights", group.Lights); err != nil {
+		return err
 	}
 
-	d.Set("name", group.Name)
-	d.Set("lights", group.Lights)
-	d.Set("type", group.Type)
+	if err := d.Set("type", group.Type); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -107,11 +140,19 @@ func resourceGroupUpdate(d *schema.ResourceData, m interface{}) error {
 func resourceGroupDelete(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
 
-	_, _, err := groups.DeleteAPI(connection, d.Id())
+	if err := checkDependents(d, connection, "group", "/groups/"+d.Id(), ""); err != nil {
+		return err
+	}
+
+	_, hueErr, err := groups.DeleteAPI(connection, d.Id())
 
 	if err != nil {
 		return err
 	}
 
+	if hueErr != nil {
+		return fmt.Errorf("bridge returned error %d deleting group %s", hueErr.Error.Type, d.Id())
+	}
+
 	return nil
 }
\ No newline at end of file
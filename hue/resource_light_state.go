@@ -0,0 +1,291 @@
+package hue
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/ghue/sdk/scenes"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/color"
+)
+
+// lightStateKeys are the attributes of lightStateSchema, in the order they're compared on read.
+var lightStateKeys = []string{
+	"state", "bri", "brightness_percent", "hue", "sat", "xy", "ct", "color", "kelvin", "effect", "transitiontime",
+	"transition",
+}
+
+func resourceLightState() *schema.Resource {
+	lightStateSchema := lightStateSchema()
+
+	// These are top level attributes, so ConflictsWith can report conflicts before the plan.
+	for key, attribute := range lightStateSchema {
+		attribute.ConflictsWith = lightStateConflictsWith(key)
+	}
+
+	lightStateSchema["light_id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+		ForceNew: true,
+	}
+
+	lightStateSchema["xy_tolerance"] = &schema.Schema{
+		Type:        schema.TypeFloat,
+		Optional:    true,
+		Default:     0.001,
+		Description: "How far each xy coordinate on the light may be from the configured value before it's considered drift.",
+	}
+
+	lightStateSchema["ct_tolerance"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Default:     2,
+		Description: "How many mireds ct on the light may be from the configured value before it's considered drift.",
+	}
+
+	lightStateSchema["hue_tolerance"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Default:     100,
+		Description: "How far hue on the light may be from the configured value before it's considered drift.",
+	}
+
+	lightStateSchema["sat_tolerance"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Default:     2,
+		Description: "How far sat on the light may be from the configured value before it's considered drift.",
+	}
+
+	return &schema.Resource{
+		Create: resourceLightStateCreate,
+		Read:   resourceLightStateRead,
+		Update: resourceLightStateUpdate,
+		Delete: resourceLightStateDelete,
+
+		CustomizeDiff: resourceLightStateCustomizeDiff,
+
+		Schema: lightStateSchema,
+	}
+}
+
+// configuredLightState collects the light state attributes of d in the form expected by expandLightState.
+func configuredLightState(d *schema.ResourceData) map[string]interface{} {
+	lightState := make(map[string]interface{})
+
+	for _, key := range append(lightStateKeys, "stored_xy", "stored_ct") {
+		lightState[key] = d.Get(key)
+	}
+
+	// These are top level attributes, so unlike in blocks GetOkExists can tell whether they're set.
+	for _, key := range []string{"hue", "sat", "transitiontime"} {
+		if _, ok := d.GetOkExists(key); !ok {
+			lightState[key] = unsetLightStateInt
+		}
+	}
+
+	return lightState
+}
+
+// setLightState converts color and kelvin for the light and sends the state, recording what they were converted to.
+func setLightState(d *schema.ResourceData, connection *common.Connection, lightId string) error {
+	lightState, err := resolveLightStateColor(connection, lightId, configuredLightState(d))
+
+	if err != nil {
+		return err
+	}
+
+	state := expandLightState(lightState)
+
+	if err := bridge.Put(connection, fmt.Sprintf("/lights/%s/state", lightId), &state); err != nil {
+		return err
+	}
+
+	if err := d.Set("stored_xy", lightState["stored_xy"]); err != nil {
+		return err
+	}
+
+	return d.Set("stored_ct", lightState["stored_ct"])
+}
+
+func resourceLightStateCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	if d.NewValueKnown("light_id") && (d.HasChange("light_id") || lightStateChanged(d)) {
+		lightId := d.Get("light_id").(string)
+
+		light, err := readLightCapabilities(connection, lightId)
+
+		if err != nil {
+			return err
+		}
+
+		lightState := make(map[string]interface{})
+
+		for _, key := range lightStateKeys {
+			lightState[key] = d.Get(key)
+		}
+
+		// A ResourceDiff can't tell an unset hue or sat from 0, so a hue of 0 is only checked on apply.
+		for _, key := range []string{"hue", "sat", "transitiontime"} {
+			if _, ok := d.GetOk(key); !ok {
+				lightState[key] = unsetLightStateInt
+			}
+		}
+
+		if err := light.checkAttributes(lightId, setAttributes(lightState)); err != nil {
+			return err
+		}
+	}
+
+	// What color and kelvin become isn't known until the light is read on apply.
+	if d.HasChange("color") {
+		if err := d.SetNewComputed("stored_xy"); err != nil {
+			return err
+		}
+	}
+
+	if d.HasChange("kelvin") {
+		return d.SetNewComputed("stored_ct")
+	}
+
+	return nil
+}
+
+func lightStateChanged(d *schema.ResourceDiff) bool {
+	for _, key := range lightStateKeys {
+		if d.HasChange(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func resourceLightStateCreate(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	lightId := d.Get("light_id").(string)
+
+	err := setLightState(d, connection, lightId)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(lightId)
+
+	return resourceLightStateRead(d, m)
+}
+
+func resourceLightStateRead(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	var light struct {
+		State scenes.LightState `json:"state"`
+	}
+
+	err := bridge.Get(connection, fmt.Sprintf("/lights/%s", d.Id()), &light)
+
+	if bridge.IsNotFound(err) {
+		d.SetId("")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	d.Set("light_id", d.Id())
+
+	// Only the attributes that are configured are enforced; anything else the light reports is left alone.  A value
+	// within tolerance of the configuration is kept as configured so the bridge's rounding doesn't show up as drift.
+	actual := light.State
+	configured := expandLightState(configuredLightState(d))
+
+	if configured.On != nil && actual.On != nil && *configured.On != *actual.On {
+		if *actual.On {
+			d.Set("state", "on")
+		} else {
+			d.Set("state", "off")
+		}
+	}
+
+	if configured.Bri != nil && actual.Bri != nil && *configured.Bri != *actual.Bri {
+		if d.Get("brightness_percent").(int) != 0 {
+			d.Set("brightness_percent", percentFromBri(*actual.Bri))
+		} else {
+			d.Set("bri", *actual.Bri)
+		}
+	}
+
+	if configured.Hue != nil && actual.Hue != nil && !hueWithinTolerance(*configured.Hue, *actual.Hue, d.Get("hue_tolerance").(int)) {
+		d.Set("hue", *actual.Hue)
+	}
+
+	if configured.Sat != nil && actual.Sat != nil && !withinTolerance(*configured.Sat, *actual.Sat, d.Get("sat_tolerance").(int)) {
+		d.Set("sat", *actual.Sat)
+	}
+
+	if configured.Effect != nil && actual.Effect != nil && *configured.Effect != *actual.Effect {
+		d.Set("effect", *actual.Effect)
+	}
+
+	// color and kelvin are compared by what they were converted to, and drift shows as the colour the light has.
+	if configured.CT != nil && actual.CT != nil && !withinTolerance(*configured.CT, *actual.CT, d.Get("ct_tolerance").(int)) {
+		if d.Get("kelvin").(int) != 0 {
+			d.Set("kelvin", color.MiredsToKelvin(*actual.CT))
+			d.Set("stored_ct", *actual.CT)
+		} else {
+			d.Set("ct", *actual.CT)
+		}
+	}
+
+	if configured.XY != nil && actual.XY != nil && !xyWithinTolerance(*configured.XY, *actual.XY, d.Get("xy_tolerance").(float64)) {
+		if d.Get("color").(string) != "" {
+			d.Set("color", color.FromXY(color.XY{X: actual.XY[0], Y: actual.XY[1]}).Hex())
+			d.Set("stored_xy", []interface{}{actual.XY[0], actual.XY[1]})
+		} else {
+			d.Set("xy", []interface{}{actual.XY[0], actual.XY[1]})
+		}
+	}
+
+	return nil
+}
+
+func resourceLightStateUpdate(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	err := setLightState(d, connection, d.Id())
+
+	if err != nil {
+		return err
+	}
+
+	return resourceLightStateRead(d, m)
+}
+
+// The light itself isn't owned by this resource, so deleting just stops enforcing its state.
+func resourceLightStateDelete(d *schema.ResourceData, m interface{}) error {
+	d.SetId("")
+
+	return nil
+}
+
+func withinTolerance(configured int, actual int, tolerance int) bool {
+	difference := configured - actual
+
+	if difference < 0 {
+		difference = -difference
+	}
+
+	return difference <= tolerance
+}
+
+// hue wraps around at 65535, so 65500 and 10 are close together.
+func hueWithinTolerance(configured int, actual int, tolerance int) bool {
+	return withinTolerance(configured, actual, tolerance) ||
+		withinTolerance(configured, actual+65536, tolerance) ||
+		withinTolerance(configured+65536, actual, tolerance)
+}
@@ -1,16 +1,46 @@
 package hue
 
 import (
+	"encoding/json"
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/lawsontyler/ghue/sdk/common"
 	"fmt"
 	"github.com/lawsontyler/ghue/sdk/rules"
-	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/constants"
-	"github.com/Sirupsen/logrus"
-	"strconv"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
 )
 
 
+// bridgeRule is a rule as returned by GET /rules/<id>, which has more to it than ghue's rules.Rule.
+type bridgeRule struct {
+	Name       string             `json:"name"`
+	Owner      string             `json:"owner"`
+	Conditions []rules.Condition  `json:"conditions"`
+	Actions    []bridgeRuleAction `json:"actions"`
+}
+
+// bridgeRuleBody is sent to create or update a rule.  ghue's rules.Create can only send light and group states.
+type bridgeRuleBody struct {
+	Name       string             `json:"name"`
+	Conditions []rules.Condition  `json:"conditions"`
+	Actions    []bridgeRuleAction `json:"actions"`
+}
+
+// bridgeRuleAction keeps the body as JSON, since it's whatever the address takes: a light or group state, but also
+// e.g. {"status": 1} for a CLIP sensor or {"status": "enabled"} for a schedule.
+type bridgeRuleAction struct {
+	Address string          `json:"address"`
+	Method  string          `json:"method"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// actionBodyKeys are what a body block can set.  Bodies with anything else are read back as body_json.
+var actionBodyKeys = map[string]bool{
+	"on": true, "bri": true, "hue": true, "sat": true, "xy": true, "ct": true, "alert": true, "effect": true,
+	"transitiontime": true, "bri_inc": true, "hue_inc": true, "sat_inc": true, "ct_inc": true, "xy_inc": true,
+	"scene": true,
+}
+
 func resourceRule() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceRuleCreate,
@@ -18,14 +48,40 @@ func resourceRule() *schema.Resource {
 		Update: resourceRuleUpdate,
 		Delete: resourceRuleDelete,
 
+		Importer: &schema.ResourceImporter{
+			State: importByIdOrName("/rules"),
+		},
+
+		CustomizeDiff: resourceRuleCustomizeDiff,
+
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceRuleV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRuleStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceRuleV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceRuleStateUpgradeV1,
+			},
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type: schema.TypeString,
 				Required: true,
 			},
+			"owner": {
+				Type: schema.TypeString,
+				Computed: true,
+				Description: "Whitelist user which created the rule.",
+			},
 			"condition": {
 				Type: schema.TypeSet,
 				Required: true,
+				Description: "All of the conditions have to be met, so their order doesn't matter.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"address": {
@@ -64,8 +120,9 @@ func resourceRule() *schema.Resource {
 			},
 
 			"action": {
-				Type: schema.TypeSet,
+				Type: schema.TypeList,
 				Required: true,
+				Description: "The bridge runs the actions in order.",
 				Elem: &schema.Resource{
 					Schema: map[string]*schema.Schema{
 						"address": {
@@ -89,82 +146,21 @@ func resourceRule() *schema.Resource {
 								return
 							},
 						},
+						"body_json": {
+							Type: schema.TypeString,
+							Optional: true,
+							Description: "A JSON object sent as the body instead of body, for addresses other than a " +
+								"light or group state.",
+							ValidateFunc: validateJSONObject,
+							DiffSuppressFunc: suppressEquivalentJSON,
+						},
 						"body": {
-							Type: schema.TypeMap,
-							Required: true,
+							Type: schema.TypeList,
+							Optional: true,
+							MaxItems: 1,
+							Description: "The light or group state to set.",
 							Elem: &schema.Resource{
-								Schema: map[string]*schema.Schema{
-									"state": {
-										Type:     schema.TypeString,
-										Optional: true,
-										ValidateFunc: validateLightOnOffState,
-									},
-
-									"bri": {
-										Type:     schema.TypeString,
-										Optional: true,
-									},
-									"hue": {
-										Type:          schema.TypeString,
-										Optional:      true,
-										ConflictsWith: []string{"action.body.xy", "action.body.ct"},
-									},
-									"sat": {
-										Type:          schema.TypeString,
-										Optional:      true,
-										ConflictsWith: []string{"action.body.xy", "action.body.ct"},
-									},
-									"xy": {
-										Type:          schema.TypeSet,
-										Optional:      true,
-										ConflictsWith: []string{"action.body.hue", "action.body.sat", "action.body.ct"},
-										Elem:          &schema.Schema{Type: schema.TypeFloat},
-										MaxItems:      2,
-									},
-									"ct": {
-										Type:          schema.TypeString,
-										Optional:      true,
-										ConflictsWith: []string{"action.body.hue", "action.body.sat", "action.body.xy"},
-									},
-									"alert": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"effect": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"transitiontime": {
-										Type: schema.TypeString,
-										Optional: true,
-										Default: 4,
-									},
-									"bri_inc": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"hue_inc": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"sat_inc": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"ct_inc": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"xy_inc": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-									"scene": {
-										Type: schema.TypeString,
-										Optional: true,
-									},
-
-								},
+								Schema: actionBodySchema(),
 							},
 						},
 					},
@@ -174,10 +170,149 @@ func resourceRule() *schema.Resource {
 	}
 }
 
+// actionBodySchema is the state a rule action sets.  It's a block within a list of actions, so ConflictsWith can't name
+// the other attributes; checkLightStateConflicts does that instead.  Unset attributes are left out of what's sent:
+// hue, sat and transitiontime use unsetLightStateInt, and everything else its zero value.
+func actionBodySchema() map[string]*schema.Schema {
+	return defaultUnsetLightStateInts(map[string]*schema.Schema{
+		"state": {
+			Type: schema.TypeString,
+			Optional: true,
+			ValidateFunc: validateLightOnOffState,
+		},
+
+		"bri": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(1, 254),
+		},
+		"brightness_percent": {
+			Type: schema.TypeInt,
+			Optional: true,
+			Description: "Brightness from 1 to 100%, converted to bri.",
+			ValidateFunc: validation.IntBetween(1, 100),
+		},
+		"hue": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(0, 65535),
+		},
+		"sat": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(0, 254),
+		},
+		"xy": {
+			Type: schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{Type: schema.TypeFloat},
+			MinItems: 2,
+			MaxItems: 2,
+			DiffSuppressFunc: suppressXYRounding,
+		},
+		"ct": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(153, 500),
+		},
+		"color": {
+			Type: schema.TypeString,
+			Optional: true,
+			Description: "A colour as #rrggbb, #rgb, rgb(r, g, b) or a CSS colour name, converted to xy.",
+			ValidateFunc: validateColor,
+		},
+		"kelvin": {
+			Type: schema.TypeInt,
+			Optional: true,
+			Description: "A colour temperature, converted to ct.",
+			ValidateFunc: validation.IntBetween(2000, 6535),
+		},
+		"stored_xy": {
+			Type: schema.TypeList,
+			Computed: true,
+			Elem: &schema.Schema{Type: schema.TypeFloat},
+			Description: "The xy stored for color, fitted to the light's gamut.",
+		},
+		"stored_ct": {
+			Type: schema.TypeInt,
+			Computed: true,
+			Description: "The ct in mireds stored for kelvin, within the light's range.",
+		},
+		"alert": {
+			Type: schema.TypeString,
+			Optional: true,
+			ValidateFunc: validation.StringInSlice([]string{"none", "select", "lselect"}, false),
+		},
+		"effect": {
+			Type: schema.TypeString,
+			Optional: true,
+			ValidateFunc: validation.StringInSlice([]string{effectNone, effectColorLoop}, false),
+		},
+		"transitiontime": {
+			Type: schema.TypeInt,
+			Optional: true,
+			Description: "In multiples of 100ms.  The bridge uses 4 if it's not set.",
+			ValidateFunc: validation.IntAtLeast(0),
+		},
+		"transition": {
+			Type: schema.TypeString,
+			Optional: true,
+			Description: "How long the light takes to change, as a duration like \"1.5s\".  Rounded to 100ms.",
+			ValidateFunc: validateTransition,
+			DiffSuppressFunc: suppressEquivalentTransition,
+		},
+		"bri_inc": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(-254, 254),
+		},
+		"hue_inc": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(-65534, 65534),
+		},
+		"sat_inc": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(-254, 254),
+		},
+		"ct_inc": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(-65534, 65534),
+		},
+		"xy_inc": {
+			Type: schema.TypeFloat,
+			Optional: true,
+			ValidateFunc: validation.FloatBetween(-0.5, 0.5),
+		},
+		"scene": {
+			Type: schema.TypeString,
+			Optional: true,
+		},
+	})
+}
+
+// actionBodyBlock returns the body block of an action, or nil if it has none.
+func actionBodyBlock(action map[string]interface{}) map[string]interface{} {
+	body, _ := action["body"].([]interface{})
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	block, _ := body[0].(map[string]interface{})
+
+	if block == nil {
+		return map[string]interface{}{}
+	}
+
+	return block
+}
+
 func dataToConditionArray(conditions *schema.Set) []rules.Condition {
 	var conditionArray []rules.Condition
 
-	logrus.Infof("Length of conditions is: %d", conditions.Len())
 	if v := conditions; v.Len() > 0 {
 		for _, v := range v.List() {
 			v := v.(map[string]interface{})
@@ -198,190 +333,349 @@ func dataToConditionArray(conditions *schema.Set) []rules.Condition {
 	return conditionArray
 }
 
-func dataToActionArray(actions *schema.Set) []rules.Action {
-	var actionArray []rules.Action
+func dataToActionArray(connection *common.Connection, actions []interface{}) ([]bridgeRuleAction, error) {
+	var actionArray []bridgeRuleAction
 
-	logrus.Infof("Length of actions is: %d", actions.Len())
-	if v := actions; v.Len() > 0 {
-		for _, v := range v.List() {
+	if v := actions; len(v) > 0 {
+		for _, v := range v {
 			v := v.(map[string]interface{})
 
 			if v["address"].(string) == "" {
 				continue
 			}
 
-			action := rules.Action{}
-			actionBody := rules.ActionBody{}
+			action := bridgeRuleAction{}
 
 			action.Address = v["address"].(string)
 			action.Method = v["method"].(string)
 
-			logrus.Errorf("Body is: %s", v["body"].(map[string]interface {}))
-
-			for key, bodyValue := range v["body"].(map[string]interface{}) {
-				logrus.Errorf("Checking %s...", key)
-				switch key {
-				case "state":
-					state := bodyValue.(string)
-					if state != "" {
-						if state == "on" {
-							v := true
-							actionBody.On = &v
-						} else {
-							v := false
-							actionBody.On = &v
-						}
-					}
-
-					break
-				case "bri":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue > 0 {
-							actionBody.Bri = &bodyValue
-						}
-					}
-					break
-				case "hue":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-							actionBody.Hue = &bodyValue
-						}
-					}
-					break
-				case "sat":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-							actionBody.Sat = &bodyValue
-						}
-					}
-					break
-				case "xy":
-					bodyValue := bodyValue.(*schema.Set)
-
-					if bodyValue.Len() > 1 {
-						actionBody.XY = &[2]float64{bodyValue.List()[0].(float64),bodyValue.List()[1].(float64)}
-					}
-					break
-				case "ct":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-							actionBody.CT = &bodyValue
-						}
-					}
-					break
-				case "alert":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						actionBody.Alert = &bodyValue
-					}
-					break
-				case "effect":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						actionBody.Effect = &bodyValue
-					}
-					break
-				case "bri_inc":
-					logrus.Errorf("Bri_inc is: %s", bodyValue.(string))
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						logrus.Errorf("bri_inc is: %s", bodyValue)
-
-						if bodyValue, err := strconv.Atoi(bodyValue); err == nil {
-							actionBody.BriInc = &bodyValue
-						}
-					}
-					break
-				case "hue_inc":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, err := strconv.Atoi(bodyValue); err == nil {
-							actionBody.HueInc = &bodyValue
-						}
-					}
-					break
-				case "sat_inc":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, err := strconv.Atoi(bodyValue); err == nil  {
-							actionBody.SatInc = &bodyValue
-						}
-					}
-					break
-				case "ct_inc":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, err := strconv.Atoi(bodyValue); err == nil {
-							actionBody.CTInc = &bodyValue
-						}
-					}
-					break
-				case "xy_inc":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, err := strconv.ParseFloat(bodyValue, 64); err == nil {
-							actionBody.XYInc = &bodyValue
-						}
-					}
-					break
-				case "scene":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						actionBody.Scene = &bodyValue
-					}
-					break
-				case "transitiontime":
-					if bodyValue := bodyValue.(string); bodyValue != "" {
-						if bodyValue, err := strconv.Atoi(bodyValue); err == nil {
-							actionBody.TransitionTime = &bodyValue
-						}
-					}
-				default:
-					continue
+			if err := checkActionBody(v); err != nil {
+				return nil, err
+			}
+
+			if bodyJSON := v["body_json"].(string); bodyJSON != "" {
+				normalised, err := normaliseJSONObject(bodyJSON)
+
+				if err != nil {
+					return nil, fmt.Errorf("body_json of action %s: %s", action.Address, err)
 				}
 
+				action.Body = json.RawMessage(normalised)
+				actionArray = append(actionArray, action)
+
+				continue
+			}
+
+			body := actionBodyBlock(v)
+			actionBody := expandActionBody(body)
+
+			if err := expandActionBodyColor(connection, action.Address, body, &actionBody); err != nil {
+				return nil, fmt.Errorf("body of action %s: %s", action.Address, err)
 			}
 
-			action.Body = actionBody
+			expandActionBodyUnits(body, &actionBody)
+
+			var err error
+
+			if action.Body, err = json.Marshal(actionBody); err != nil {
+				return nil, err
+			}
 
 			actionArray = append(actionArray, action)
 		}
 	}
 
-	logrus.Errorf("Action Array is: %s", actionArray)
+	return actionArray, nil
+}
+
+// expandActionBody converts the attributes of an action's body block (see actionBodySchema) into the body sent to the
+// bridge, apart from color, kelvin, brightness_percent and transition.
+func expandActionBody(body map[string]interface{}) rules.ActionBody {
+	var actionBody rules.ActionBody
+
+	if state, _ := body["state"].(string); state != "" {
+		on := state == "on"
+		actionBody.On = &on
+	}
+
+	actionBody.Bri = expandActionBodyNonZero(body["bri"])
+	actionBody.Hue = expandLightStateInt(body["hue"])
+	actionBody.Sat = expandLightStateInt(body["sat"])
+	actionBody.XY = expandXY(body["xy"])
+	actionBody.CT = expandActionBodyNonZero(body["ct"])
+	actionBody.TransitionTime = expandLightStateInt(body["transitiontime"])
+
+	if alert, _ := body["alert"].(string); alert != "" {
+		actionBody.Alert = &alert
+	}
+
+	if effect, _ := body["effect"].(string); effect != "" {
+		actionBody.Effect = &effect
+	}
+
+	actionBody.BriInc = expandActionBodyNonZero(body["bri_inc"])
+	actionBody.HueInc = expandActionBodyNonZero(body["hue_inc"])
+	actionBody.SatInc = expandActionBodyNonZero(body["sat_inc"])
+	actionBody.CTInc = expandActionBodyNonZero(body["ct_inc"])
+
+	if xyInc, _ := body["xy_inc"].(float64); xyInc != 0 {
+		actionBody.XYInc = &xyInc
+	}
+
+	if scene, _ := body["scene"].(string); scene != "" {
+		actionBody.Scene = &scene
+	}
+
+	return actionBody
+}
+
+// expandActionBodyNonZero returns nil for an unset body attribute which can't be 0, like bri and the increments.
+func expandActionBodyNonZero(value interface{}) *int {
+	v, ok := value.(int)
+
+	if !ok || v == 0 {
+		return nil
+	}
+
+	return &v
+}
+
+// flattenActionBody is the reverse of expandActionBody.
+func flattenActionBody(actionBody rules.ActionBody) map[string]interface{} {
+	body := map[string]interface{}{
+		"hue":            unsetLightStateInt,
+		"sat":            unsetLightStateInt,
+		"transitiontime": unsetLightStateInt,
+	}
+
+	if actionBody.On != nil {
+		if *actionBody.On {
+			body["state"] = "on"
+		} else {
+			body["state"] = "off"
+		}
+	}
+
+	optionalInts := map[string]*int{
+		"bri":            actionBody.Bri,
+		"hue":            actionBody.Hue,
+		"sat":            actionBody.Sat,
+		"ct":             actionBody.CT,
+		"transitiontime": actionBody.TransitionTime,
+		"bri_inc":        actionBody.BriInc,
+		"hue_inc":        actionBody.HueInc,
+		"sat_inc":        actionBody.SatInc,
+		"ct_inc":         actionBody.CTInc,
+	}
+
+	for key, value := range optionalInts {
+		if value != nil {
+			body[key] = *value
+		}
+	}
+
+	if actionBody.XY != nil {
+		body["xy"] = []interface{}{actionBody.XY[0], actionBody.XY[1]}
+	}
+
+	if actionBody.XYInc != nil {
+		body["xy_inc"] = *actionBody.XYInc
+	}
+
+	if actionBody.Alert != nil {
+		body["alert"] = *actionBody.Alert
+	}
+
+	if actionBody.Effect != nil {
+		body["effect"] = *actionBody.Effect
+	}
+
+	if actionBody.Scene != nil {
+		body["scene"] = *actionBody.Scene
+	}
+
+	return body
+}
+
+// expandActionBodyColor converts color and kelvin in an action body to xy and ct, for the light the action addresses if
+// it's a single light's state.
+func expandActionBodyColor(connection *common.Connection, address string, body map[string]interface{}, actionBody *rules.ActionBody) error {
+	colorValue, _ := body["color"].(string)
+	kelvin, _ := body["kelvin"].(int)
+
+	if colorValue == "" && kelvin == 0 {
+		return nil
+	}
+
+	light, err := actionLightCapabilities(connection, address)
+
+	if err != nil {
+		return err
+	}
+
+	if colorValue != "" {
+		if actionBody.XY, err = light.colorXY(colorValue); err != nil {
+			return err
+		}
+	}
+
+	if kelvin != 0 {
+		ct := light.kelvinCT(kelvin)
+		actionBody.CT = &ct
+	}
+
+	return nil
+}
+
+// carryActionColorForward puts color and kelvin back into an action body read from the bridge when what's stored is
+// still what they convert to, so rules written with them don't show a diff.
+func carryActionColorForward(connection *common.Connection, action rules.Action, body map[string]interface{}, previous map[string]interface{}) error {
+	var converted rules.ActionBody
+
+	if err := expandActionBodyColor(connection, action.Address, previous, &converted); err != nil {
+		return err
+	}
+
+	if converted.XY != nil && action.Body.XY != nil && xyWithinTolerance(*converted.XY, *action.Body.XY, colorXYTolerance) {
+		body["color"] = previous["color"]
+		body["stored_xy"] = body["xy"]
+		delete(body, "xy")
+	}
+
+	if converted.CT != nil && action.Body.CT != nil && withinTolerance(*converted.CT, *action.Body.CT, colorCTTolerance) {
+		body["kelvin"] = previous["kelvin"]
+		body["stored_ct"] = body["ct"]
+		delete(body, "ct")
+	}
+
+	return nil
+}
+
+// expandActionBodyUnits converts brightness_percent and transition in an action body to bri and transitiontime.
+func expandActionBodyUnits(body map[string]interface{}, actionBody *rules.ActionBody) {
+	if percent, _ := body["brightness_percent"].(int); percent > 0 {
+		bri := briFromPercent(percent)
+		actionBody.Bri = &bri
+	}
+
+	if transition, _ := body["transition"].(string); transition != "" {
+		if transitionTime, err := parseTransition(transition); err == nil {
+			actionBody.TransitionTime = &transitionTime
+		}
+	}
+}
+
+// carryActionUnitsForward reads bri and transitiontime back as brightness_percent and transition in an action body
+// that was written with them, like carryUnitsForward.
+func carryActionUnitsForward(action rules.Action, body map[string]interface{}, previous map[string]interface{}) {
+	if percent, _ := previous["brightness_percent"].(int); percent > 0 && action.Body.Bri != nil {
+		body["brightness_percent"] = percentFromBri(*action.Body.Bri)
+		delete(body, "bri")
+	}
+
+	if transition, _ := previous["transition"].(string); transition != "" && action.Body.TransitionTime != nil {
+		body["transition"] = formatTransition(*action.Body.TransitionTime)
+
+		if previousTransitionTime, err := parseTransition(transition); err == nil && previousTransitionTime == *action.Body.TransitionTime {
+			body["transition"] = transition
+		}
 
-	return actionArray
+		body["transitiontime"] = unsetLightStateInt
+	}
+}
+
+// resourceRuleCustomizeDiff checks each action's body, and that actions setting a single light's state only use what
+// the light can do.  Lights in a group can differ, so group actions aren't checked against them.
+func resourceRuleCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	if !d.HasChange("action") || !d.NewValueKnown("action") {
+		return nil
+	}
+
+	for _, action := range d.Get("action").([]interface{}) {
+		action := action.(map[string]interface{})
+
+		if err := checkActionBody(action); err != nil {
+			return err
+		}
+
+		body := actionBodyBlock(action)
+
+		if err := checkLightStateConflicts(body); err != nil {
+			return fmt.Errorf("action %s: %s", action["address"], err)
+		}
+
+		match := lightStateAddress.FindStringSubmatch(action["address"].(string))
+
+		if match == nil {
+			continue
+		}
+
+		light, err := readLightCapabilities(connection, match[1])
+
+		if err != nil {
+			return err
+		}
+
+		if err := light.checkAttributes(match[1], setAttributes(body)); err != nil {
+			return fmt.Errorf("action %s: %s", action["address"], err)
+		}
+	}
+
+	return nil
 }
 
 func resourceRuleCreate(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
 
 	conditions := dataToConditionArray(d.Get("condition").(*schema.Set))
-	actions := dataToActionArray(d.Get("action").(*schema.Set))
+	actions, err := dataToActionArray(connection, d.Get("action").([]interface{}))
 
-	rule := rules.Create{
+	if err != nil {
+		return err
+	}
+
+	rule := bridgeRuleBody{
 		Name: d.Get("name").(string),
 		Conditions: conditions,
 		Actions: actions,
 	}
 
-	result, _, err := rules.CreateAPI(connection, &rule)
+	ruleId, err := bridge.Post(connection, "/rules", &rule)
 
 	if err != nil {
 		return err
 	}
 
-	d.SetId(result.Success.Id)
+	d.SetId(ruleId)
 
-	return nil
+	// Read back what color and kelvin were stored as.
+	return resourceRuleRead(d, m)
 }
 
 func resourceRuleRead(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
-	logrus.Errorf("In resourceRuleRead %s", d.Id())
 
-	rule, hueErr, err := rules.GetRule(connection, d.Id())
+	var rule bridgeRule
 
-	logrus.Infof("Rule is: %s", rule)
+	err := bridge.Get(connection, "/rules/"+d.Id(), &rule)
 
-	if err != nil && hueErr != nil && hueErr.Error.Type == int(constants.NOT_FOUND) {
+	if bridge.IsNotFound(err) {
 		d.SetId("")
 		return nil
 	}
 
+	if err != nil {
+		return err
+	}
+
 	d.Set("name", rule.Name)
+	d.Set("owner", rule.Owner)
+
+	// Actions written with color or kelvin are matched up with what the bridge has by position, as long as the address
+	// and method are the same.
+	previousActions := d.Get("action").([]interface{})
 
 	conditions := make([]map[string]interface{}, 0, len(rule.Conditions))
 	actions    := make([]map[string]interface{}, 0, len(rule.Actions))
@@ -393,85 +687,49 @@ func resourceRuleRead(d *schema.ResourceData, m interface{}) error {
 		}
 
 		if ruleCondition.Value != nil {
-			condition["value"] = ruleCondition.Value
+			condition["value"] = *ruleCondition.Value
 		}
 
 		conditions = append(conditions, condition)
 	}
-	// logrus.Errorf("Rule Actions: %s", rule.Actions)
 
+	for i, rawAction := range rule.Actions {
+		previous := previousAction(previousActions, i, rawAction)
 
-	for _, ruleAction := range rule.Actions {
+		if bodyJSON, ok := actionBodyJSON(rawAction, previous); ok {
+			actions = append(actions, map[string]interface{}{
+				"address":   rawAction.Address,
+				"method":    rawAction.Method,
+				"body_json": bodyJSON,
+			})
 
+			continue
+		}
 
-		// if ruleAction.Body.BriInc != nil {
-			// logrus.Errorf("Body: %s", strconv.Itoa(*ruleAction.Body.BriInc))
-		// }
-
-		body := map[string]interface{} {}
+		ruleAction := rules.Action{Address: rawAction.Address, Method: rawAction.Method}
 
-		if ruleAction.Body.On != nil {
-			if *ruleAction.Body.On == true {
-				body["state"] = "on"
-			} else {
-				body["state"] = "off"
+		if len(rawAction.Body) > 0 {
+			if err := json.Unmarshal(rawAction.Body, &ruleAction.Body); err != nil {
+				return fmt.Errorf("could not read the body of action %s in rule %s: %s", rawAction.Address, d.Id(), err)
 			}
 		}
 
-		if ruleAction.Body.Bri != nil {
-			body["bri"] = strconv.Itoa(*ruleAction.Body.Bri)
-		}
-		if ruleAction.Body.Hue != nil {
-			body["hue"] = strconv.Itoa(*ruleAction.Body.Hue)
-		}
-		if ruleAction.Body.Sat != nil {
-			body["sat"] = strconv.Itoa(*ruleAction.Body.Sat)
-		}
-		if ruleAction.Body.CT != nil {
-			body["ct"] = strconv.Itoa(*ruleAction.Body.CT)
-		}
-		if ruleAction.Body.XY != nil {
-			body["xy"] = *ruleAction.Body.XY
-		}
-		if ruleAction.Body.Alert != nil {
-			body["alert"] = ruleAction.Body.Alert
-		}
-		if ruleAction.Body.Effect != nil {
-			body["effect"] = ruleAction.Body.Effect
-		}
-
-		if ruleAction.Body.BriInc != nil {
-			body["bri_inc"] = strconv.Itoa(*ruleAction.Body.BriInc)
-		}
+		body := flattenActionBody(ruleAction.Body)
 
-		if ruleAction.Body.HueInc != nil {
-			body["hue_inc"] = strconv.Itoa(*ruleAction.Body.HueInc)
-		}
-		if ruleAction.Body.SatInc != nil {
-			body["sat_inc"] = strconv.Itoa(*ruleAction.Body.SatInc)
-		}
-		if ruleAction.Body.CTInc != nil {
-			body["ct_inc"] = strconv.Itoa(*ruleAction.Body.CTInc)
-		}
-		if ruleAction.Body.XYInc != nil {
-			body["xy_inc"] = strconv.FormatFloat(*ruleAction.Body.XYInc, 'f', 3, 64)
-		}
-		if ruleAction.Body.Scene != nil {
-			body["scene"] = *ruleAction.Body.Scene
-		}
+		if previous != nil {
+			if previousBody := actionBodyBlock(previous); previousBody != nil {
+				if err := carryActionColorForward(connection, ruleAction, body, previousBody); err != nil {
+					return err
+				}
 
-		if ruleAction.Body.TransitionTime != nil {
-			body["transitiontime"] = strconv.Itoa(*ruleAction.Body.TransitionTime)
+				carryActionUnitsForward(ruleAction, body, previousBody)
+			}
 		}
 
-		// bodySet := make([]interface{}, 0, 1)
-
-		// bodySet = append(bodySet, body)
-
 		action := map[string]interface{}{
 			"address": ruleAction.Address,
 			"method": ruleAction.Method,
-			"body": body,
+			"body": []interface{}{body},
 		}
 
 		actions = append(actions, action)
@@ -483,25 +741,137 @@ func resourceRuleRead(d *schema.ResourceData, m interface{}) error {
 	return nil
 }
 
+// previousAction returns the action at i in state if it's the same action as the bridge has there.
+func previousAction(previousActions []interface{}, i int, rawAction bridgeRuleAction) map[string]interface{} {
+	if i >= len(previousActions) {
+		return nil
+	}
+
+	previous, ok := previousActions[i].(map[string]interface{})
+
+	if !ok || previous["method"] != rawAction.Method || previous["address"] != rawAction.Address {
+		return nil
+	}
+
+	return previous
+}
+
+// actionBodyJSON returns the body of an action as body_json if that's how it was written, or if it has anything a
+// body block can't set.  body_json that's the same JSON as the bridge has keeps the way it was written.
+func actionBodyJSON(rawAction bridgeRuleAction, previous map[string]interface{}) (string, bool) {
+	previousJSON, _ := previous["body_json"].(string)
+
+	var body map[string]interface{}
+
+	if err := json.Unmarshal(rawAction.Body, &body); err != nil {
+		return "", false
+	}
+
+	asJSON := previousJSON != ""
+
+	for key := range body {
+		asJSON = asJSON || !actionBodyKeys[key]
+	}
+
+	if !asJSON {
+		return "", false
+	}
+
+	normalised, err := normaliseJSONObject(string(rawAction.Body))
+
+	if err != nil {
+		return "", false
+	}
+
+	if previousNormalised, err := normaliseJSONObject(previousJSON); err == nil && previousNormalised == normalised {
+		return previousJSON, true
+	}
+
+	return normalised, true
+}
+
+// checkActionBody makes sure an action has either body or body_json.  Its list element is a map, so ConflictsWith
+// can't be used.
+func checkActionBody(action map[string]interface{}) error {
+	body := actionBodyBlock(action)
+	bodyJSON, _ := action["body_json"].(string)
+
+	if body != nil && bodyJSON != "" {
+		return fmt.Errorf("action %s: body and body_json can't both be set", action["address"])
+	}
+
+	if body == nil && bodyJSON == "" {
+		return fmt.Errorf("action %s: one of body or body_json is required", action["address"])
+	}
+
+	return nil
+}
+
+// normaliseJSONObject parses a JSON object and writes it back out compactly with its keys sorted.
+func normaliseJSONObject(value string) (string, error) {
+	var object map[string]interface{}
+
+	if err := json.Unmarshal([]byte(value), &object); err != nil || object == nil {
+		return "", fmt.Errorf("%q is not a JSON object", value)
+	}
+
+	normalised, err := json.Marshal(object)
+
+	if err != nil {
+		return "", err
+	}
+
+	return string(normalised), nil
+}
+
+func validateJSONObject(i interface{}, s string) (_ []string, errors []error) {
+	if _, err := normaliseJSONObject(i.(string)); err != nil {
+		errors = append(errors, fmt.Errorf("%q: %s", s, err))
+	}
+
+	return
+}
+
+// suppressEquivalentJSON ignores differences in spacing and key order.
+func suppressEquivalentJSON(k, old, new string, d *schema.ResourceData) bool {
+	oldNormalised, err := normaliseJSONObject(old)
+
+	if err != nil {
+		return false
+	}
+
+	newNormalised, err := normaliseJSONObject(new)
+
+	if err != nil {
+		return false
+	}
+
+	return oldNormalised == newNormalised
+}
+
 func resourceRuleUpdate(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
 
 	conditions := dataToConditionArray(d.Get("condition").(*schema.Set))
-	actions := dataToActionArray(d.Get("action").(*schema.Set))
+	actions, err := dataToActionArray(connection, d.Get("action").([]interface{}))
+
+	if err != nil {
+		return err
+	}
 
-	rule := rules.Update {
+	rule := bridgeRuleBody{
 		Name: d.Get("name").(string),
 		Conditions: conditions,
 		Actions: actions,
 	}
 
-	_, _, err := rules.UpdateAPI(connection, d.Id(), &rule)
+	err = bridge.Put(connection, "/rules/"+d.Id(), &rule)
 
 	if err != nil {
-		return err
+		return fmt.Errorf("could not update rule %s: %s", d.Id(), err)
 	}
 
-	return nil
+	return resourceRuleRead(d, m)
 }
 
 func resourceRuleDelete(d *schema.ResourceData, m interface{}) error {
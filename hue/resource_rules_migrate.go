@@ -0,0 +1,166 @@
+package hue
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// Previous versions of the rule schema.  Only the types matter here; they're used to read state written by older
+// versions of the provider so it can be upgraded.
+
+// Version 0 had action as a set, which lost the order the bridge runs them in.
+func resourceRuleV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"condition": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"address": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"operator": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"value": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+					},
+				},
+			},
+			"action": {
+				Type:     schema.TypeSet,
+				Required: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"address": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"method": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"body": {
+							Type:     schema.TypeMap,
+							Required: true,
+							Elem:     &schema.Schema{Type: schema.TypeString},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Version 1 had actions in order and body_json, but body was a map, so everything in it was a string.
+func resourceRuleV1() *schema.Resource {
+	resource := resourceRuleV0()
+	action := resource.Schema["action"]
+
+	action.Type = schema.TypeList
+	action.Elem.(*schema.Resource).Schema["body"].Optional = true
+	action.Elem.(*schema.Resource).Schema["body"].Required = false
+	action.Elem.(*schema.Resource).Schema["body_json"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+	}
+
+	return resource
+}
+
+// resourceRuleStateUpgradeV0 keeps the actions as they are.  A set is stored as a list, just in no particular order, so
+// the next refresh puts them in the order the bridge has them.
+func resourceRuleStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	return rawState, nil
+}
+
+// resourceRuleStateUpgradeV1 turns each action's body map into a body block.
+func resourceRuleStateUpgradeV1(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if actions, ok := rawState["action"].([]interface{}); ok {
+		for _, action := range actions {
+			if action, ok := action.(map[string]interface{}); ok {
+				upgradeActionBodyV1(action)
+			}
+		}
+	}
+
+	return rawState, nil
+}
+
+// upgradeActionBodyV1 converts the body's values from strings.  Values which don't parse were rejected before being
+// sent to the bridge, so they're dropped, as is an xy the map couldn't hold; the next refresh reads back what the bridge
+// has.
+func upgradeActionBodyV1(action map[string]interface{}) {
+	body, _ := action["body"].(map[string]interface{})
+
+	if len(body) == 0 {
+		action["body"] = []interface{}{}
+		return
+	}
+
+	block := map[string]interface{}{
+		"hue":            unsetLightStateInt,
+		"sat":            unsetLightStateInt,
+		"transitiontime": unsetLightStateInt,
+	}
+
+	for key, value := range body {
+		value, ok := value.(string)
+
+		if !ok || value == "" {
+			continue
+		}
+
+		switch key {
+		case "bri", "brightness_percent", "hue", "sat", "ct", "kelvin", "transitiontime", "bri_inc", "hue_inc", "sat_inc", "ct_inc":
+			if parsed, err := strconv.Atoi(value); err == nil {
+				block[key] = parsed
+			}
+		case "xy_inc":
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				block[key] = parsed
+			}
+		case "xy":
+			if xy := upgradeXYStringV1(value); xy != nil {
+				block[key] = xy
+			}
+		default:
+			block[key] = value
+		}
+	}
+
+	action["body"] = []interface{}{block}
+}
+
+// upgradeXYStringV1 parses an xy which was flattened into a string, e.g. "[0.3, 0.4]".
+func upgradeXYStringV1(value string) []interface{} {
+	parts := strings.Split(strings.Trim(value, "[] "), ",")
+
+	if len(parts) != 2 {
+		return nil
+	}
+
+	x, xErr := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	y, yErr := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+
+	if xErr != nil || yErr != nil {
+		return nil
+	}
+
+	return []interface{}{x, y}
+}
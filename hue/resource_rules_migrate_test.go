@@ -0,0 +1,178 @@
+package hue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResourceRuleStateUpgradeV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"name": "Dimmer on",
+		"action": []interface{}{
+			map[string]interface{}{
+				"address": "/groups/1/action",
+				"method":  "PUT",
+				"body":    map[string]interface{}{"scene": "abc123"},
+			},
+			map[string]interface{}{
+				"address": "/sensors/2/state",
+				"method":  "PUT",
+				"body":    map[string]interface{}{"status": "1"},
+			},
+		},
+	}
+
+	want := map[string]interface{}{
+		"name": "Dimmer on",
+		"action": []interface{}{
+			map[string]interface{}{
+				"address": "/groups/1/action",
+				"method":  "PUT",
+				"body":    map[string]interface{}{"scene": "abc123"},
+			},
+			map[string]interface{}{
+				"address": "/sensors/2/state",
+				"method":  "PUT",
+				"body":    map[string]interface{}{"status": "1"},
+			},
+		},
+	}
+
+	got, err := resourceRuleStateUpgradeV0(rawState, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("state = %#v, want %#v", got, want)
+	}
+}
+
+func TestUpgradeActionBodyV1(t *testing.T) {
+	cases := []struct {
+		name string
+		body interface{}
+		want []interface{}
+	}{
+		{
+			name: "missing",
+			body: nil,
+			want: []interface{}{},
+		},
+		{
+			name: "empty",
+			body: map[string]interface{}{},
+			want: []interface{}{},
+		},
+		{
+			name: "scene",
+			body: map[string]interface{}{"scene": "abc123"},
+			want: []interface{}{map[string]interface{}{
+				"scene":          "abc123",
+				"hue":            unsetLightStateInt,
+				"sat":            unsetLightStateInt,
+				"transitiontime": unsetLightStateInt,
+			}},
+		},
+		{
+			name: "numbers",
+			body: map[string]interface{}{
+				"state":          "on",
+				"bri":            "254",
+				"hue":            "0",
+				"sat":            "200",
+				"transitiontime": "4",
+				"bri_inc":        "-30",
+				"xy_inc":         "0.05",
+				"xy":             "[0.3, 0.4]",
+			},
+			want: []interface{}{map[string]interface{}{
+				"state":          "on",
+				"bri":            254,
+				"hue":            0,
+				"sat":            200,
+				"transitiontime": 4,
+				"bri_inc":        -30,
+				"xy_inc":         0.05,
+				"xy":             []interface{}{0.3, 0.4},
+			}},
+		},
+		{
+			name: "unparseable",
+			body: map[string]interface{}{
+				"bri":   "bright",
+				"ct":    "",
+				"xy":    "0.3",
+				"hue":   "red",
+				"alert": "select",
+			},
+			want: []interface{}{map[string]interface{}{
+				"alert":          "select",
+				"hue":            unsetLightStateInt,
+				"sat":            unsetLightStateInt,
+				"transitiontime": unsetLightStateInt,
+			}},
+		},
+	}
+
+	for _, c := range cases {
+		action := map[string]interface{}{"address": "/groups/0/action", "method": "PUT", "body": c.body}
+
+		upgradeActionBodyV1(action)
+
+		if !reflect.DeepEqual(action["body"], c.want) {
+			t.Errorf("%s: body = %#v, want %#v", c.name, action["body"], c.want)
+		}
+	}
+}
+
+func TestResourceRuleStateUpgradeV1(t *testing.T) {
+	rawState := map[string]interface{}{
+		"name": "Dimmer on",
+		"action": []interface{}{
+			map[string]interface{}{
+				"address": "/groups/1/action",
+				"method":  "PUT",
+				"body":    map[string]interface{}{"scene": "abc123"},
+			},
+			map[string]interface{}{
+				"address":   "/sensors/2/state",
+				"method":    "PUT",
+				"body_json": `{"status":1}`,
+			},
+		},
+	}
+
+	want := map[string]interface{}{
+		"name": "Dimmer on",
+		"action": []interface{}{
+			map[string]interface{}{
+				"address": "/groups/1/action",
+				"method":  "PUT",
+				"body": []interface{}{map[string]interface{}{
+					"scene":          "abc123",
+					"hue":            unsetLightStateInt,
+					"sat":            unsetLightStateInt,
+					"transitiontime": unsetLightStateInt,
+				}},
+			},
+			map[string]interface{}{
+				"address":   "/sensors/2/state",
+				"method":    "PUT",
+				"body_json": `{"status":1}`,
+				"body":      []interface{}{},
+			},
+		},
+	}
+
+	got, err := resourceRuleStateUpgradeV1(rawState, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("state = %#v, want %#v", got, want)
+	}
+}
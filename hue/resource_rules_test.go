@@ -0,0 +1,34 @@
+package hue
+
+import "testing"
+
+func TestNormaliseJSONObject(t *testing.T) {
+	cases := []struct {
+		value string
+		want  string
+	}{
+		{`{}`, `{}`},
+		{`{"on": true}`, `{"on":true}`},
+		{`{ "scene": "abc", "bri": 100 }`, `{"bri":100,"scene":"abc"}`},
+		{`{"xy": [0.3, 0.4], "transitiontime": 4}`, `{"transitiontime":4,"xy":[0.3,0.4]}`},
+		{"{\n  \"status\": 1\n}", `{"status":1}`},
+	}
+
+	for _, c := range cases {
+		got, err := normaliseJSONObject(c.value)
+
+		if err != nil {
+			t.Errorf("normaliseJSONObject(%q): %s", c.value, err)
+		} else if got != c.want {
+			t.Errorf("normaliseJSONObject(%q) = %q, want %q", c.value, got, c.want)
+		}
+	}
+}
+
+func TestNormaliseJSONObjectInvalid(t *testing.T) {
+	for _, value := range []string{"", "null", "[]", `"on"`, "1", `{"on": true`, "{on: true}"} {
+		if got, err := normaliseJSONObject(value); err == nil {
+			t.Errorf("normaliseJSONObject(%q) = %q, want an error", value, got)
+		}
+	}
+}
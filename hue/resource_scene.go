@@ -2,13 +2,65 @@ package hue
 
 import (
 	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
 	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/ghue/sdk/groups"
 	"github.com/lawsontyler/ghue/sdk/scenes"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
 	"fmt"
+	"github.com/Sirupsen/logrus"
+	"math"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 
+// bridgeScene is a scene as returned by GET /scenes/<id>, which has more to it than ghue's scenes.Scene.
+type bridgeScene struct {
+	Name        string                       `json:"name"`
+	Type        string                       `json:"type"`
+	Group       string                       `json:"group"`
+	Lights      []string                     `json:"lights"`
+	Owner       string                       `json:"owner"`
+	Locked      bool                         `json:"locked"`
+	LastUpdated string                       `json:"lastupdated"`
+	Recycle     bool                         `json:"recycle"`
+	AppData     sceneAppData                 `json:"appdata"`
+	Picture     string                       `json:"picture"`
+	Lightstates map[string]scenes.LightState `json:"lightstates"`
+}
+
+// sceneAppData is kept by the bridge for apps.  The Hue app uses it to show which room a scene is for and its picture.
+type sceneAppData struct {
+	Version int    `json:"version"`
+	Data    string `json:"data"`
+}
+
+// bridgeSceneBody is sent to create or update a scene.  ghue's scenes.Create has no way to make a GroupScene, and
+// a GroupScene's lights come from its group so must be left out.
+type bridgeSceneBody struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type,omitempty"`
+	Group   string   `json:"group,omitempty"`
+	Lights  []string `json:"lights,omitempty"`
+	Recycle bool     `json:"recycle"`
+
+	AppData *sceneAppData `json:"appdata,omitempty"`
+	Picture string        `json:"picture,omitempty"`
+
+	Lightstates map[string]scenes.LightState `json:"lightstates,omitempty"`
+}
+
+// Bridges from API 1.29 accept light states when the scene is created.
+const sceneCreateLightStatesVersion = "1.29.0"
+
+const (
+	lightScene = "LightScene"
+	groupScene = "GroupScene"
+)
+
 func resourceScene() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceSceneCreate,
@@ -16,6 +68,30 @@ func resourceScene() *schema.Resource {
 		Update: resourceSceneUpdate,
 		Delete: resourceSceneDelete,
 
+		Importer: &schema.ResourceImporter{
+			State: importByIdOrName("/scenes"),
+		},
+
+		CustomizeDiff: resourceSceneCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		SchemaVersion: 2,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceSceneV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceSceneStateUpgradeV0,
+			},
+			{
+				Version: 1,
+				Type:    resourceSceneV1().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceSceneStateUpgradeV1,
+			},
+		},
+
 		Schema: map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
@@ -26,98 +102,608 @@ func resourceScene() *schema.Resource {
 				Optional: true,
 				Default: true,
 			},
+			"type": {
+				Type: schema.TypeString,
+				Optional: true,
+				Default: lightScene,
+				ForceNew: true,
+				ValidateFunc: validation.StringInSlice([]string{lightScene, groupScene}, false),
+			},
+			"group": {
+				Type: schema.TypeString,
+				Optional: true,
+				ForceNew: true,
+				Description: "Group a GroupScene belongs to.  The scene's lights are the group's lights.",
+			},
+			"owner": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Whitelist user which created the scene.",
+			},
+			"locked": {
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether a rule or schedule uses the scene, which stops it being deleted.",
+			},
+			"wait_for_dependents": waitForDependentsSchema(),
+			"appdata_version": {
+				Type: schema.TypeInt,
+				Optional: true,
+				Computed: true,
+				Description: "Version of appdata_data, which is up to the app that reads it.",
+			},
+			"appdata_data": {
+				Type: schema.TypeString,
+				Optional: true,
+				Computed: true,
+				Description: "Data for apps, e.g. the room and picture codes the Hue app shows the scene with.",
+				ValidateFunc: validation.StringLenBetween(0, 16),
+			},
+			"picture": {
+				Type: schema.TypeString,
+				Optional: true,
+				Computed: true,
+				Description: "Id of the scene's picture in the Hue app.",
+				ValidateFunc: validation.StringLenBetween(0, 16),
+			},
+			"lights": {
+				Type: schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Schema{Type: schema.TypeString},
+				Set: schema.HashString,
+				Description: "Lights to capture when capture_current is set.  Otherwise they're the lights in light_state.",
+			},
+			"capture_current": {
+				Type: schema.TypeBool,
+				Optional: true,
+				Default: false,
+				Description: "Store whatever the lights are currently showing instead of light_state.  What was " +
+					"captured is read back into captured_light_state.",
+			},
+			"capture_trigger": {
+				Type: schema.TypeString,
+				Optional: true,
+				Description: "Changing this captures the lights' current state again when capture_current is set.",
+			},
 			"light_state": {
 				Type: schema.TypeSet,
-				Required: true,
+				Optional: true,
 				Elem: &schema.Resource{
-
-					Schema: map[string]*schema.Schema{
-						"light_id": {
-							Type:     schema.TypeString,
-							Required: true,
-						},
-
-						"state": {
-							Type: schema.TypeString,
-							Optional: true,
-							ValidateFunc: validateLightOnOffState,
-						},
-
-						"bri": {
-							Type:     schema.TypeString,
-							Optional: true,
-						},
-						"hue": {
-							Type: schema.TypeString,
-							Optional: true,
-							ConflictsWith: []string{"light_state.xy", "light_state.ct"},
-						},
-						"sat": {
-							Type: schema.TypeString,
-							Optional: true,
-							ConflictsWith: []string{"light_state.xy", "light_state.ct"},
-						},
-						"xy": {
-							Type: schema.TypeSet,
-							Optional: true,
-							ConflictsWith: []string{"light_state.hue", "light_state.sat", "light_state.ct"},
-							Elem: &schema.Schema{Type: schema.TypeFloat},
-							MaxItems: 2,
-						},
-						"ct": {
-							Type: schema.TypeString,
-							Optional: true,
-							ConflictsWith: []string{"light_state.hue", "light_state.sat", "light_state.xy"},
-						},
-						"transitiontime": {
-							Type: schema.TypeString,
-							Optional: true,
-						},
-					},
+					Schema: sceneLightStateSchema(),
 				},
+				Set: sceneLightStateHash(),
+			},
+			"captured_light_state": {
+				Type: schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: computedLightStateSchema(),
+				},
+				Description: "The light states stored when capture_current is set.",
 			},
 		},
 	}
 }
 
+func sceneLightStateSchema() map[string]*schema.Schema {
+	lightStateSchema := defaultUnsetLightStateInts(lightStateSchema())
+
+	lightStateSchema["light_id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+	}
+
+	return lightStateSchema
+}
+
+// sceneLightStateHash hashes light_state blocks with xy rounded the way the bridge stores it, transition as the
+// transitiontime it stands for and an effect of "none" as no effect, so that reading a scene back doesn't look like a
+// different set of light states.
+func sceneLightStateHash() schema.SchemaSetFunc {
+	hash := schema.HashResource(&schema.Resource{Schema: sceneLightStateSchema()})
+
+	return func(v interface{}) int {
+		lightState := make(map[string]interface{})
+
+		for key, value := range v.(map[string]interface{}) {
+			lightState[key] = value
+		}
+
+		if xy := expandXY(lightState["xy"]); xy != nil {
+			lightState["xy"] = []interface{}{roundXY(xy[0]), roundXY(xy[1])}
+		}
+
+		if transition, _ := lightState["transition"].(string); transition != "" {
+			if transitionTime, err := parseTransition(transition); err == nil {
+				lightState["transition"] = formatTransition(transitionTime)
+			}
+		}
+
+		if lightState["effect"] == effectNone {
+			lightState["effect"] = ""
+		}
+
+		return hash(lightState)
+	}
+}
+
+// The bridge stores xy to 4 decimal places.
+const xyPrecision = 10000
+
+func roundXY(coordinate float64) float64 {
+	return math.Round(coordinate*xyPrecision) / xyPrecision
+}
+
+// suppressXYRounding ignores differences in an xy coordinate which are only the bridge's rounding.
+func suppressXYRounding(k, old, new string, d *schema.ResourceData) bool {
+	oldCoordinate, err := strconv.ParseFloat(old, 64)
+
+	if err != nil {
+		return false
+	}
+
+	newCoordinate, err := strconv.ParseFloat(new, 64)
+
+	if err != nil {
+		return false
+	}
+
+	return roundXY(oldCoordinate) == roundXY(newCoordinate)
+}
+
+// expandXY reads an ordered [x, y] list, returning nil if it isn't one.
+func expandXY(v interface{}) *[2]float64 {
+	xy, ok := v.([]interface{})
+
+	if !ok || len(xy) != 2 {
+		return nil
+	}
+
+	x, xOk := xy[0].(float64)
+	y, yOk := xy[1].(float64)
+
+	if !xOk || !yOk {
+		return nil
+	}
+
+	return &[2]float64{x, y}
+}
+
+// lightStateSchema is the set of attributes describing a light's state, shared by scene light states and the
+// light_state resource.  Attributes in blocks can't use ConflictsWith, so lightStateConflicts lists what can't be used
+// together instead.
+func lightStateSchema() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"state": {
+			Type: schema.TypeString,
+			Optional: true,
+			ValidateFunc: validateLightOnOffState,
+		},
+
+		"bri": {
+			Type:     schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(1, 254),
+		},
+		"brightness_percent": {
+			Type: schema.TypeInt,
+			Optional: true,
+			Description: "Brightness from 1 to 100%, converted to bri.",
+			ValidateFunc: validation.IntBetween(1, 100),
+		},
+		"hue": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(0, 65535),
+		},
+		"sat": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(0, 254),
+		},
+		"xy": {
+			Type: schema.TypeList,
+			Optional: true,
+			Elem: &schema.Schema{Type: schema.TypeFloat},
+			MinItems: 2,
+			MaxItems: 2,
+			DiffSuppressFunc: suppressXYRounding,
+		},
+		"ct": {
+			Type: schema.TypeInt,
+			Optional: true,
+			ValidateFunc: validation.IntBetween(153, 500),
+		},
+		"color": {
+			Type: schema.TypeString,
+			Optional: true,
+			Description: "A colour as #rrggbb, #rgb, rgb(r, g, b) or a CSS colour name, converted to xy for the light.",
+			ValidateFunc: validateColor,
+		},
+		"kelvin": {
+			Type: schema.TypeInt,
+			Optional: true,
+			Description: "A colour temperature, converted to ct for the light.",
+			ValidateFunc: validation.IntBetween(2000, 6535),
+		},
+		"stored_xy": {
+			Type: schema.TypeList,
+			Computed: true,
+			Elem: &schema.Schema{Type: schema.TypeFloat},
+			Description: "The xy stored for color, fitted to the light's gamut.",
+		},
+		"stored_ct": {
+			Type: schema.TypeInt,
+			Computed: true,
+			Description: "The ct in mireds stored for kelvin, within the light's range.",
+		},
+		"effect": {
+			Type: schema.TypeString,
+			Optional: true,
+			Description: "\"colorloop\" to cycle through every hue, or \"none\".",
+			ValidateFunc: validation.StringInSlice([]string{effectNone, effectColorLoop}, false),
+			DiffSuppressFunc: suppressNoEffect,
+		},
+		"transitiontime": {
+			Type: schema.TypeInt,
+			Optional: true,
+			Description: "In multiples of 100ms.",
+			ValidateFunc: validation.IntAtLeast(0),
+		},
+		"transition": {
+			Type: schema.TypeString,
+			Optional: true,
+			Description: "How long the light takes to change, as a duration like \"1.5s\".  Rounded to 100ms.",
+			ValidateFunc: validateTransition,
+			DiffSuppressFunc: suppressEquivalentTransition,
+		},
+	}
+}
+
+// lightStateConflicts are the groups of light state and action body attributes which can't be used together.
+var lightStateConflicts = [][]string{
+	{"hue", "xy", "ct", "color", "kelvin"},
+	{"sat", "xy", "ct", "color", "kelvin"},
+	{"bri", "brightness_percent"},
+	{"transitiontime", "transition"},
+}
+
+// checkLightStateConflicts enforces lightStateConflicts on a light state or action body.
+func checkLightStateConflicts(values map[string]interface{}) error {
+	set := make(map[string]bool)
+
+	for _, key := range setAttributes(values) {
+		set[key] = true
+	}
+
+	for _, conflicts := range lightStateConflicts {
+		var used []string
+
+		for _, key := range conflicts {
+			if set[key] {
+				used = append(used, key)
+			}
+		}
+
+		if len(used) > 1 {
+			return fmt.Errorf("%s can't be used together", strings.Join(used, " and "))
+		}
+	}
+
+	return nil
+}
+
+// lightStateConflictsWith lists the attributes key can't be used with, for light state attributes at the top level of a
+// resource, where ConflictsWith works.
+func lightStateConflictsWith(key string) []string {
+	var conflictsWith []string
+	seen := map[string]bool{key: true}
+
+	for _, conflicts := range lightStateConflicts {
+		if !containsString(conflicts, key) {
+			continue
+		}
+
+		for _, other := range conflicts {
+			if !seen[other] {
+				seen[other] = true
+				conflictsWith = append(conflictsWith, other)
+			}
+		}
+	}
+
+	return conflictsWith
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	effectNone      = "none"
+	effectColorLoop = "colorloop"
+)
+
+// suppressNoEffect treats an effect of "none", which the bridge reports for colour lights, the same as no effect.
+func suppressNoEffect(k, old, new string, d *schema.ResourceData) bool {
+	return (old == "" || old == effectNone) && (new == "" || new == effectNone)
+}
+
+// hue, sat and transitiontime can all be 0, so -1 stands for not being set.  bri and ct can't be 0, so 0 does.
+const unsetLightStateInt = -1
+
+// defaultUnsetLightStateInts makes hue, sat and transitiontime default to unsetLightStateInt.  It's for attributes in
+// blocks, where the SDK reads one that's left out as 0 and can't tell it wasn't set.  Plans show the -1, so the
+// descriptions say what it means.
+func defaultUnsetLightStateInts(attributes map[string]*schema.Schema) map[string]*schema.Schema {
+	for _, key := range []string{"hue", "sat", "transitiontime"} {
+		attributes[key].Default = unsetLightStateInt
+		attributes[key].Description = strings.TrimSpace(attributes[key].Description + "  Shown as -1 when it's not set, " +
+			"which isn't sent to the bridge.")
+	}
+
+	return attributes
+}
+
+// lightStateIntRanges are the values the bridge accepts for each integer light state attribute; -1 means no maximum.
+var lightStateIntRanges = map[string][2]int{
+	"bri":                {1, 254},
+	"brightness_percent": {1, 100},
+	"hue":                {0, 65535},
+	"sat":                {0, 254},
+	"ct":                 {153, 500},
+	"kelvin":             {2000, 6535},
+	"transitiontime":     {0, -1},
+}
+
+// expandLightStateInt returns nil for an unset integer light state attribute.
+func expandLightStateInt(value interface{}) *int {
+	v, ok := value.(int)
+
+	if !ok || v < 0 {
+		return nil
+	}
+
+	return &v
+}
+
 func resourceSceneCreate(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
 	d.Partial(true)
 
-	// Step 1: Set the light states
-	// Seizure warning is especially important here.
-
 	lightStates := d.Get("light_state").(*schema.Set).List()
 
-	lightsInScene := getLightsInScene(lightStates)
+	scene, err := sceneBody(connection, d, lightStates)
+
+	if err != nil {
+		return err
+	}
 
-	// Now that we know the lights in the scene and have set their state, we can create the scene and capture it.
+	capture := d.Get("capture_current").(bool)
 
-	scene := scenes.Create{
-		Name:    d.Get("name").(string),
-		Lights:  lightsInScene,
-		Recycle: d.Get("recycle").(bool),
+	lightStatesInCreate, err := bridge.SupportsAPIVersion(connection, sceneCreateLightStatesVersion)
+
+	if err != nil {
+		return err
+	}
+
+	if capture {
+		lightStatesInCreate = false
+	} else if lightStates, err = resolveLightStates(connection, lightStates); err != nil {
+		return err
+	}
+
+	if lightStatesInCreate {
+		scene.Lightstates = make(map[string]scenes.LightState)
+
+		for _, lightState := range lightStates {
+			lightState := lightState.(map[string]interface{})
+			scene.Lightstates[lightState["light_id"].(string)] = expandLightState(lightState)
+		}
 	}
 
-	sceneResult, _, err := scenes.CreateApi(connection, &scene)
+	sceneId, err := bridge.Post(connection, "/scenes", scene)
 
 	if err != nil {
 		return err
 	}
 
+	// The id is recorded straight away so that if storing a light state fails, the scene is tainted rather than left
+	// on the bridge unmanaged.
+	d.SetId(sceneId)
 	d.SetPartial("name")
 
-	// Now that it's created...and all the light states are wrong, let's run an update to set them.
+	if capture {
+		err = captureLightStates(connection, sceneId)
+
+		if err != nil {
+			return err
+		}
+
+		d.Partial(false)
+
+		return resourceSceneRead(d, m)
+	}
+
+	if !lightStatesInCreate {
+		// Older bridges create the scene with whatever the lights are showing, so each light state is stored after.
+		_, err = setLightStates(connection, sceneId, lightStates)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	d.Partial(false)
+
+	return d.Set("light_state", lightStates)
+}
+
+// resolveLightStates converts color and kelvin in each light state for its light; see resolveLightStateColor.
+func resolveLightStates(connection *common.Connection, lightStates []interface{}) ([]interface{}, error) {
+	resolved := make([]interface{}, 0, len(lightStates))
+
+	for _, lightState := range lightStates {
+		lightState := lightState.(map[string]interface{})
 
-	err = setLightStates(connection, sceneResult.Success.Id, lightStates)
+		resolvedLightState, err := resolveLightStateColor(connection, lightState["light_id"].(string), lightState)
+
+		if err != nil {
+			return nil, err
+		}
+
+		resolved = append(resolved, resolvedLightState)
+	}
+
+	return resolved, nil
+}
+
+// captureLightStates stores what each light in the scene is currently showing as its state in the scene.
+func captureLightStates(connection *common.Connection, sceneId string) error {
+	err := bridge.Put(connection, "/scenes/"+sceneId, map[string]interface{}{"storelightstate": true})
+
+	if err != nil {
+		return fmt.Errorf("could not capture the current light states in scene %s: %s", sceneId, err)
+	}
+
+	return nil
+}
+
+// sceneBody builds the scene sent to the bridge.  A LightScene's lights are the ones in light_state; a GroupScene's
+// come from its group, which is checked to contain every light in light_state.
+func sceneBody(connection *common.Connection, d *schema.ResourceData, lightStates []interface{}) (*bridgeSceneBody, error) {
+	lightsInScene := getLightsInScene(lightStates)
+
+	if d.Get("capture_current").(bool) {
+		lightsInScene = dataToLightArray(d.Get("lights").(*schema.Set))
+	}
+
+	scene := &bridgeSceneBody{
+		Name:    d.Get("name").(string),
+		Type:    d.Get("type").(string),
+		Recycle: d.Get("recycle").(bool),
+		Picture: d.Get("picture").(string),
+	}
+
+	if appDataVersion, appData := d.Get("appdata_version").(int), d.Get("appdata_data").(string); appDataVersion != 0 || appData != "" {
+		scene.AppData = &sceneAppData{Version: appDataVersion, Data: appData}
+	}
+
+	if scene.Type != groupScene {
+		scene.Lights = lightsInScene
+		return scene, nil
+	}
+
+	scene.Group = d.Get("group").(string)
+
+	if err := validateSceneGroupLights(connection, scene.Group, lightsInScene); err != nil {
+		return nil, err
+	}
+
+	// Lights can't be changed on a GroupScene, so none are sent on update either.
+	return scene, nil
+}
+
+func validateSceneGroupLights(connection *common.Connection, groupId string, lightsInScene []string) error {
+	group, hueErr, err := groups.GetGroup(connection, groupId)
 
 	if err != nil {
 		return err
 	}
 
-	d.Partial(false)
+	if hueErr != nil {
+		return fmt.Errorf("bridge returned error %d reading group %s", hueErr.Error.Type, groupId)
+	}
+
+	lightsInGroup := make(map[string]bool)
+
+	for _, lightId := range group.Lights {
+		lightsInGroup[lightId] = true
+	}
+
+	for _, lightId := range lightsInScene {
+		if !lightsInGroup[lightId] {
+			return fmt.Errorf("light %s has a light_state but is not in group %s (%s)", lightId, groupId, group.Name)
+		}
+	}
+
+	return nil
+}
+
+func resourceSceneCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	sceneType := d.Get("type").(string)
+	groupId := d.Get("group").(string)
+
+	if sceneType == groupScene && groupId == "" && d.NewValueKnown("group") {
+		return fmt.Errorf("group is required when type is %s", groupScene)
+	}
+
+	if sceneType != groupScene && groupId != "" {
+		return fmt.Errorf("group can only be set when type is %s", groupScene)
+	}
+
+	capture := d.Get("capture_current").(bool)
+
+	if capture {
+		// Checked here rather than with ConflictsWith so that capture_current = false can be written with light_state.
+		if d.Get("light_state").(*schema.Set).Len() > 0 {
+			return fmt.Errorf("light_state can't be set when capture_current is set; what's captured is read into captured_light_state")
+		}
+
+		lights := d.Get("lights").(*schema.Set)
+
+		if sceneType != groupScene && d.NewValueKnown("lights") && lights.Len() == 0 {
+			return fmt.Errorf("lights is required when capture_current is set, unless type is %s", groupScene)
+		}
+
+		// What will be captured isn't known until apply.
+		if d.HasChange("capture_trigger") || d.HasChange("capture_current") || d.HasChange("lights") {
+			return d.SetNewComputed("captured_light_state")
+		}
+
+		return nil
+	}
+
+	// light_state isn't computed, so this is what's configured rather than what's in state.
+	if d.NewValueKnown("light_state") && d.Get("light_state").(*schema.Set).Len() == 0 {
+		return fmt.Errorf("at least one light_state is required unless capture_current is set")
+	}
+
+	lightStates := d.Get("light_state").(*schema.Set).List()
+
+	// Whatever is known is checked, since conflicting attributes would otherwise be sent in no particular order.
+	for _, lightState := range lightStates {
+		lightState := lightState.(map[string]interface{})
+
+		if err := checkLightStateConflicts(lightState); err != nil {
+			return fmt.Errorf("light_state for light %s: %s", lightState["light_id"], err)
+		}
+	}
+
+	// The group and lights are often ids of resources that don't exist yet; they're checked again on apply.
+	if !d.NewValueKnown("light_state") {
+		return nil
+	}
+
+	// Reading every light is only worth it when the light states change.
+	if d.HasChange("light_state") {
+		if err := validateLightStateCapabilities(connection, lightStates); err != nil {
+			return err
+		}
+	}
 
-	d.SetId(sceneResult.Success.Id)
+	if sceneType == groupScene && d.NewValueKnown("group") {
+		return validateSceneGroupLights(connection, groupId, getLightsInScene(lightStates))
+	}
 
 	return nil
 }
@@ -133,143 +719,290 @@ func getLightsInScene(lightStates []interface{}) []string {
 	return lightsInScene
 }
 
-func setLightStates(connection *common.Connection, sceneId string, lightStates []interface{}) error {
+// setLightStates stores each light's state in the scene, stopping at the first one which fails.  The lights whose state
+// was stored before then are returned so that they can be rolled back.
+func setLightStates(connection *common.Connection, sceneId string, lightStates []interface{}) ([]string, error) {
+	var stored []string
+
 	for _, lightState := range lightStates {
 		lightState := lightState.(map[string]interface{})
-		var updateLightState scenes.LightState
-
-		for key, bodyValue := range lightState {
-			switch key {
-			case "state":
-				if bodyValue := bodyValue.(string); bodyValue != "" {
-					if bodyValue == "on" {
-						v := true
-						updateLightState.On = &v
-					} else {
-						v := false
-						updateLightState.On = &v
-					}
-				}
-				break
-			case "bri":
-				if bodyValue := bodyValue.(string); bodyValue != "" {
-					if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-						updateLightState.Bri = &bodyValue
-					}
-				}
-				break
-			case "hue":
-				if bodyValue := bodyValue.(string); bodyValue != "" {
-					if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-						updateLightState.Hue = &bodyValue
-					}
-				}
-				break
-			case "sat":
-				if bodyValue := bodyValue.(string); bodyValue != "" {
-					if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-						updateLightState.Sat = &bodyValue
-					}
-				}
-				break
-			case "ct":
-				if bodyValue := bodyValue.(string); bodyValue != "" {
-					if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-						updateLightState.CT = &bodyValue
-					}
-				}
-				break
-			case "xy":
-				if bodyValue := bodyValue.(*schema.Set); bodyValue.Len() > 1 {
-					updateLightState.XY = &[2]float64{ bodyValue.List()[0].(float64), bodyValue.List()[1].(float64) }
+		updateLightState := expandLightState(lightState)
+
+		lightId := lightState["light_id"].(string)
+
+		_, hueErr, err := scenes.UpdateSceneLightState(connection, sceneId, lightId , &updateLightState)
+
+		if err == nil && hueErr != nil {
+			err = fmt.Errorf("bridge returned error %d", hueErr.Error.Type)
+		}
+
+		if err != nil {
+			return stored, fmt.Errorf("could not store the state of light %s in scene %s: %s", lightId, sceneId, err)
+		}
+
+		stored = append(stored, lightId)
+	}
+
+	return stored, nil
+}
+
+// expandLightState converts light state attributes (see lightStateSchema) into the state sent to the bridge.
+func expandLightState(lightState map[string]interface{}) scenes.LightState {
+	var updateLightState scenes.LightState
+
+	for key, bodyValue := range lightState {
+		switch key {
+		case "state":
+			if bodyValue := bodyValue.(string); bodyValue != "" {
+				if bodyValue == "on" {
+					v := true
+					updateLightState.On = &v
+				} else {
+					v := false
+					updateLightState.On = &v
 				}
-				break
-			case "transitiontime":
-				if bodyValue := bodyValue.(string); bodyValue != "" {
-					if bodyValue, _ := strconv.Atoi(bodyValue); bodyValue >= 0 {
-						updateLightState.TransitionTime = &bodyValue
-					}
+			}
+			break
+		case "bri":
+			if bri := expandLightStateInt(bodyValue); bri != nil && *bri > 0 {
+				updateLightState.Bri = bri
+			}
+			break
+		case "hue":
+			updateLightState.Hue = expandLightStateInt(bodyValue)
+			break
+		case "sat":
+			updateLightState.Sat = expandLightStateInt(bodyValue)
+			break
+		case "ct":
+			if ct := expandLightStateInt(bodyValue); ct != nil && *ct > 0 {
+				updateLightState.CT = ct
+			}
+			break
+		case "xy":
+			updateLightState.XY = expandXY(bodyValue)
+			break
+		case "brightness_percent":
+			if percent := expandLightStateInt(bodyValue); percent != nil && *percent > 0 {
+				bri := briFromPercent(*percent)
+				updateLightState.Bri = &bri
+			}
+			break
+		case "transitiontime":
+			if transitionTime := expandLightStateInt(bodyValue); transitionTime != nil {
+				updateLightState.TransitionTime = transitionTime
+			}
+			break
+		case "transition":
+			if transition, _ := bodyValue.(string); transition != "" {
+				if transitionTime, err := parseTransition(transition); err == nil {
+					updateLightState.TransitionTime = &transitionTime
 				}
-				break
 			}
+			break
+		case "effect":
+			if effect, _ := bodyValue.(string); effect != "" {
+				updateLightState.Effect = &effect
+			}
+			break
 		}
+	}
 
-		lightId := lightState["light_id"].(string)
+	// color and kelvin are sent as what they were converted to; see resolveLightStateColor.
+	if colorValue, _ := lightState["color"].(string); colorValue != "" {
+		updateLightState.XY = expandXY(lightState["stored_xy"])
+	}
 
-		_, _, err := scenes.UpdateSceneLightState(connection, sceneId, lightId , &updateLightState)
+	if kelvin, _ := lightState["kelvin"].(int); kelvin != 0 {
+		if ct, _ := lightState["stored_ct"].(int); ct > 0 {
+			updateLightState.CT = &ct
+		}
+	}
 
-		if err != nil {
-			return err
+	return updateLightState
+}
+
+// flattenLightState is the reverse of expandLightState.
+func flattenLightState(lightState scenes.LightState) map[string]interface{} {
+	state := map[string]interface{}{
+		"hue":            unsetLightStateInt,
+		"sat":            unsetLightStateInt,
+		"transitiontime": unsetLightStateInt,
+	}
+
+	if lightState.On != nil {
+		if true == *lightState.On {
+			state["state"] = "on"
+		} else {
+			state["state"] = "off"
 		}
 	}
 
-	return nil
+	if lightState.Bri != nil {
+		state["bri"] = *lightState.Bri
+	}
+
+	if lightState.Hue != nil {
+		state["hue"] = *lightState.Hue
+	}
+
+	if lightState.Sat != nil {
+		state["sat"] = *lightState.Sat
+	}
+
+	if lightState.CT != nil {
+		state["ct"] = *lightState.CT
+	}
+
+	if lightState.TransitionTime != nil {
+		state["transitiontime"] = *lightState.TransitionTime
+	}
+
+	if lightState.XY != nil {
+		state["xy"] = []interface{}{lightState.XY[0], lightState.XY[1]}
+	}
+
+	if lightState.Effect != nil {
+		state["effect"] = *lightState.Effect
+	}
+
+	return state
 }
 
 func resourceSceneRead(d *schema.ResourceData, m interface{}) error {
 
 	connection := m.(*common.Connection)
 
-	scene, _, err := scenes.GetScene(connection, d.Id())
+	var scene bridgeScene
 
-	if err != nil {
+	err := bridge.Get(connection, "/scenes/"+d.Id(), &scene)
+
+	if bridge.IsNotFound(err) {
+		logrus.Warnf("Scene %s not found on the bridge, removing from state", d.Id())
 		d.SetId("")
+		return nil
+	}
+
+	if err != nil {
 		return err
 	}
 
-	d.Set("name", scene.Name)
+	// Light states are kept as they were configured while the bridge still has what they became, whether that's color
+	// and kelvin converted or values the bridge normalised.
+	previousLightStates := make(map[string]map[string]interface{})
 
-	d.Set("recycle", scene.Recycle)
+	for _, lightState := range d.Get("light_state").(*schema.Set).List() {
+		lightState := lightState.(map[string]interface{})
+		previousLightStates[lightState["light_id"].(string)] = lightState
+	}
 
-	var lightStates []map[string]interface{}
+	// Map order isn't stable, so light states are read in light id order.
+	lightIds := make([]string, 0, len(scene.Lightstates))
 
-	for lightId, lightState := range scene.Lightstates {
-		state := make(map[string]interface{})
-		state["light_id"] = lightId
+	for lightId := range scene.Lightstates {
+		lightIds = append(lightIds, lightId)
+	}
 
-		if lightState.On != nil {
-			if true == *lightState.On {
-				state["state"] = "on"
-			} else {
-				state["state"] = "off"
-			}
-		}
+	sort.Strings(lightIds)
 
-		if lightState.Bri != nil {
-			state["bri"] = strconv.Itoa(*lightState.Bri)
-		}
+	var lightStates []map[string]interface{}
+	var capturedLightStates []map[string]interface{}
 
-		if lightState.Hue != nil {
-			state["hue"] = strconv.Itoa(*lightState.Hue)
-		}
+	capture := d.Get("capture_current").(bool)
 
-		if lightState.Sat != nil {
-			state["sat"] = strconv.Itoa(*lightState.Sat)
-		}
+	// The bridge keeps a light state for every light in a GroupScene's group.  Only the ones already managed are read
+	// into light_state, so a scene configured for some of the group's lights doesn't show the rest as a diff; all of
+	// them are in lights.  An imported scene has none yet, so it gets them all.
+	onlyPrevious := scene.Type == groupScene && len(previousLightStates) > 0
 
-		if lightState.CT != nil {
-			state["ct"] = strconv.Itoa(*lightState.CT)
+	for _, lightId := range lightIds {
+		// A captured scene's light states are whatever the lights were showing, so they're kept apart from light_state.
+		if capture {
+			capturedLightStates = append(capturedLightStates, flattenComputedLightState(lightId, scene.Lightstates[lightId]))
+			continue
 		}
 
-		if lightState.TransitionTime != nil {
-			state["transitiontime"] = strconv.Itoa(*lightState.TransitionTime)
-		}
+		previous, ok := previousLightStates[lightId]
 
-		set := schema.Set{}
-		if lightState.XY != nil {
-			set.Add(lightState.XY[0])
-			set.Add(lightState.XY[1])
-			state["xy"] = &set
+		if onlyPrevious && !ok {
+			continue
 		}
 
-		if lightState.Effect != nil {
-			state["effect"] = *lightState.Effect
+		state := flattenLightState(scene.Lightstates[lightId])
+		state["light_id"] = lightId
+
+		if ok {
+			carryColorForward(state, previous)
+			carryUnitsForward(state, previous)
+
+			if err := carryNormalisedForward(connection, lightId, state, previous); err != nil {
+				return err
+			}
 		}
 
 		lightStates = append(lightStates, state)
 	}
 
-	d.Set("light_state", lightStates)
+	// Everything is built before anything is set, so a bad response never leaves half a scene in state.
+	if err := d.Set("name", scene.Name); err != nil {
+		return err
+	}
+
+	if err := d.Set("recycle", scene.Recycle); err != nil {
+		return err
+	}
+
+	if err := d.Set("owner", scene.Owner); err != nil {
+		return err
+	}
+
+	if err := d.Set("locked", scene.Locked); err != nil {
+		return err
+	}
+
+	if err := d.Set("appdata_version", scene.AppData.Version); err != nil {
+		return err
+	}
+
+	if err := d.Set("appdata_data", scene.AppData.Data); err != nil {
+		return err
+	}
+
+	if err := d.Set("picture", scene.Picture); err != nil {
+		return err
+	}
+
+	// Scenes made by bridges older than API 1.28 have no type and are always LightScenes.
+	sceneType := scene.Type
+
+	if sceneType == "" {
+		sceneType = lightScene
+	}
+
+	if err := d.Set("type", sceneType); err != nil {
+		return err
+	}
+
+	if err := d.Set("group", scene.Group); err != nil {
+		return err
+	}
+
+	if err := d.Set("lights", scene.Lights); err != nil {
+		return err
+	}
+
+	if err := d.Set("light_state", lightStates); err != nil {
+		return err
+	}
+
+	if err := d.Set("captured_light_state", capturedLightStates); err != nil {
+		return err
+	}
+
+	// capture_current is only in the configuration, so an imported scene starts from its default.
+	if err := d.Set("capture_current", capture); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -277,39 +1010,178 @@ func resourceSceneRead(d *schema.ResourceData, m interface{}) error {
 func resourceSceneUpdate(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
 
+	// Nothing is marked as saved until every call has succeeded, so a failed update leaves the old state behind.
 	d.Partial(true)
 
+	var previous bridgeScene
+
+	err := bridge.Get(connection, "/scenes/"+d.Id(), &previous)
+
+	if err != nil {
+		return err
+	}
+
 	lightStates := d.Get("light_state").(*schema.Set).List()
-	lightsInScene := getLightsInScene(lightStates)
 
-	// Now that we know the lights in the scene and have set their state, we can create the scene and capture it.
+	if !d.Get("capture_current").(bool) {
+		lightStates, err = resolveLightStates(connection, lightStates)
 
-	scene := scenes.Update{
-		Name:    d.Get("name").(string),
-		Lights:  lightsInScene,
-		Recycle: d.Get("recycle").(bool),
+		if err != nil {
+			return err
+		}
 	}
 
-	scenes.UpdateAPI(connection, d.Id(), &scene)
+	scene, err := sceneBody(connection, d, lightStates)
 
-	d.SetPartial("name")
+	if err != nil {
+		return err
+	}
+
+	// The type and group can't be changed, so they're not sent.
+	scene.Type = ""
+	scene.Group = ""
+
+	err = bridge.Put(connection, "/scenes/"+d.Id(), scene)
+
+	if err != nil {
+		return fmt.Errorf("could not update scene %s: %s", d.Id(), err)
+	}
+
+	if d.Get("capture_current").(bool) {
+		if d.HasChange("capture_trigger") || d.HasChange("capture_current") || d.HasChange("lights") {
+			err = captureLightStates(connection, d.Id())
+
+			if err != nil {
+				return rollbackScene(connection, d.Id(), &previous, scene.Lights, nil, err)
+			}
+		}
+
+		d.Partial(false)
+
+		return resourceSceneRead(d, m)
+	}
 
-	setLightStates(connection, d.Id(), lightStates)
+	clearPreviousEffects(lightStates, &previous)
+
+	stored, err := setLightStates(connection, d.Id(), lightStates)
+
+	if err != nil {
+		return rollbackScene(connection, d.Id(), &previous, scene.Lights, stored, err)
+	}
 
 	d.Partial(false)
 
-	return nil
+	return d.Set("light_state", lightStates)
+}
+
+// clearPreviousEffects turns off an effect a light had in the scene when its light state no longer has one, since
+// storing a light state leaves out whatever isn't in it.
+func clearPreviousEffects(lightStates []interface{}, previous *bridgeScene) {
+	for _, lightState := range lightStates {
+		lightState := lightState.(map[string]interface{})
+
+		if effect, _ := lightState["effect"].(string); effect != "" {
+			continue
+		}
+
+		previousLightState, ok := previous.Lightstates[lightState["light_id"].(string)]
+
+		if ok && previousLightState.Effect != nil && *previousLightState.Effect != effectNone {
+			lightState["effect"] = effectNone
+		}
+	}
+}
+
+// rollbackScene puts back the name, lights and light states a scene had before an update failed part way through.
+// lights are the lights the update put in the scene.  It returns updateErr, along with whatever couldn't be restored.
+func rollbackScene(connection *common.Connection, sceneId string, previous *bridgeScene, lights []string, stored []string, updateErr error) error {
+	var rollbackErrors []string
+
+	restore := bridgeSceneBody{
+		Name:    previous.Name,
+		Recycle: previous.Recycle,
+		Picture: previous.Picture,
+	}
+
+	if previous.AppData.Version != 0 || previous.AppData.Data != "" {
+		restore.AppData = &previous.AppData
+	}
+
+	if previous.Type != groupScene {
+		restore.Lights = previous.Lights
+	}
+
+	if err := bridge.Put(connection, "/scenes/"+sceneId, &restore); err != nil {
+		rollbackErrors = append(rollbackErrors, err.Error())
+	}
+
+	for _, lightId := range lightsToRestore(previous, lights, stored) {
+		lightState := previous.Lightstates[lightId]
+
+		if err := bridge.Put(connection, fmt.Sprintf("/scenes/%s/lightstates/%s", sceneId, lightId), &lightState); err != nil {
+			rollbackErrors = append(rollbackErrors, fmt.Sprintf("light %s: %s", lightId, err))
+		}
+	}
+
+	if len(rollbackErrors) > 0 {
+		return fmt.Errorf("%s; restoring the previous scene also failed: %s", updateErr, strings.Join(rollbackErrors, "; "))
+	}
+
+	return fmt.Errorf("%s; scene %s was restored to its previous state", updateErr, sceneId)
+}
+
+// lightsToRestore lists the lights whose previous state has to be stored again after a failed update: those whose
+// state was stored, and those the update took out of a LightScene, since putting a light back records whatever it's
+// showing.  A light that's new to the scene has no previous state to go back to.
+func lightsToRestore(previous *bridgeScene, lights []string, stored []string) []string {
+	restore := make(map[string]bool)
+
+	for _, lightId := range stored {
+		restore[lightId] = true
+	}
+
+	if previous.Type != groupScene {
+		kept := make(map[string]bool)
+
+		for _, lightId := range lights {
+			kept[lightId] = true
+		}
+
+		for lightId := range previous.Lightstates {
+			restore[lightId] = restore[lightId] || !kept[lightId]
+		}
+	}
+
+	var lightIds []string
+
+	for lightId := range previous.Lightstates {
+		if restore[lightId] {
+			lightIds = append(lightIds, lightId)
+		}
+	}
+
+	sort.Strings(lightIds)
+
+	return lightIds
 }
 
 func resourceSceneDelete(d *schema.ResourceData, m interface{}) error {
 	connection := m.(*common.Connection)
 
-	_, _, err := scenes.DeleteAPI(connection, d.Id())
+	if err := checkDependents(d, connection, "scene", "/scenes/"+d.Id(), d.Id()); err != nil {
+		return err
+	}
+
+	_, hueErr, err := scenes.DeleteAPI(connection, d.Id())
 
 	if err != nil {
 		return err
 	}
 
+	if hueErr != nil {
+		return fmt.Errorf("bridge returned error %d deleting scene %s", hueErr.Error.Type, d.Id())
+	}
+
 	return nil
 }
 
@@ -0,0 +1,174 @@
+package hue
+
+import (
+	"strconv"
+
+	"github.com/hashicorp/terraform/helper/schema"
+)
+
+// Previous versions of the scene schema.  Only the types matter here; they're used to read state written by older
+// versions of the provider so it can be upgraded.
+
+// Version 0 had xy as a set, which lost the order of x and y.
+func resourceSceneV0() *schema.Resource {
+	return resourceSceneWithLightState(lightStateSchemaV0())
+}
+
+// Version 1 had bri, hue, sat, ct and transitiontime as strings.
+func resourceSceneV1() *schema.Resource {
+	return resourceSceneWithLightState(lightStateSchemaV1())
+}
+
+func resourceSceneWithLightState(lightState map[string]*schema.Schema) *schema.Resource {
+	lightState["light_id"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Required: true,
+	}
+
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"recycle": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"type": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"group": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"lights": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+			},
+			"capture_current": {
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"capture_trigger": {
+				Type:     schema.TypeString,
+				Optional: true,
+			},
+			"light_state": {
+				Type:     schema.TypeSet,
+				Optional: true,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: lightState,
+				},
+			},
+		},
+	}
+}
+
+func lightStateSchemaV0() map[string]*schema.Schema {
+	return map[string]*schema.Schema{
+		"state": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"bri": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"hue": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"sat": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"xy": {
+			Type:     schema.TypeSet,
+			Optional: true,
+			Elem:     &schema.Schema{Type: schema.TypeFloat},
+		},
+		"ct": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+		"transitiontime": {
+			Type:     schema.TypeString,
+			Optional: true,
+		},
+	}
+}
+
+func lightStateSchemaV1() map[string]*schema.Schema {
+	lightState := lightStateSchemaV0()
+
+	lightState["xy"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
+		Elem:     &schema.Schema{Type: schema.TypeFloat},
+	}
+
+	return lightState
+}
+
+func resourceSceneStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if lightStates, ok := rawState["light_state"].([]interface{}); ok {
+		for _, lightState := range lightStates {
+			if lightState, ok := lightState.(map[string]interface{}); ok {
+				upgradeLightStateXYV0(lightState)
+			}
+		}
+	}
+
+	return rawState, nil
+}
+
+// upgradeLightStateXYV0 turns an xy set into a list.  Sets kept no order, so x and y may be swapped until the next
+// refresh reads them back from the bridge, and [0.3, 0.3] was stored as a single value.
+func upgradeLightStateXYV0(lightState map[string]interface{}) {
+	xy, ok := lightState["xy"].([]interface{})
+
+	if ok && len(xy) == 1 {
+		lightState["xy"] = []interface{}{xy[0], xy[0]}
+	}
+}
+
+func resourceSceneStateUpgradeV1(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	if lightStates, ok := rawState["light_state"].([]interface{}); ok {
+		for _, lightState := range lightStates {
+			if lightState, ok := lightState.(map[string]interface{}); ok {
+				upgradeLightStateIntsV1(lightState)
+			}
+		}
+	}
+
+	return rawState, nil
+}
+
+// upgradeLightStateIntsV1 converts the integer attributes from strings.  Empty or unparseable strings were never sent
+// to the bridge, so they become unset.
+func upgradeLightStateIntsV1(lightState map[string]interface{}) {
+	for _, key := range []string{"bri", "hue", "sat", "ct", "transitiontime"} {
+		value := unsetLightStateInt
+
+		if key == "bri" || key == "ct" {
+			value = 0
+		}
+
+		if s, ok := lightState[key].(string); ok {
+			if parsed, err := strconv.Atoi(s); err == nil {
+				value = parsed
+			}
+		}
+
+		lightState[key] = value
+	}
+}
@@ -0,0 +1,149 @@
+package hue
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUpgradeLightStateXYV0(t *testing.T) {
+	cases := []struct {
+		xy   interface{}
+		want interface{}
+	}{
+		{nil, nil},
+		{[]interface{}{}, []interface{}{}},
+		{[]interface{}{0.3}, []interface{}{0.3, 0.3}},
+		{[]interface{}{0.3, 0.4}, []interface{}{0.3, 0.4}},
+	}
+
+	for _, c := range cases {
+		lightState := map[string]interface{}{"light_id": "1", "xy": c.xy}
+
+		upgradeLightStateXYV0(lightState)
+
+		if !reflect.DeepEqual(lightState["xy"], c.want) {
+			t.Errorf("xy %#v became %#v, want %#v", c.xy, lightState["xy"], c.want)
+		}
+	}
+}
+
+func TestResourceSceneStateUpgradeV0(t *testing.T) {
+	rawState := map[string]interface{}{
+		"name": "Evening",
+		"light_state": []interface{}{
+			map[string]interface{}{"light_id": "1", "bri": "200", "xy": []interface{}{0.3}},
+			map[string]interface{}{"light_id": "2", "bri": "100", "xy": []interface{}{0.4, 0.5}},
+		},
+	}
+
+	want := map[string]interface{}{
+		"name": "Evening",
+		"light_state": []interface{}{
+			map[string]interface{}{"light_id": "1", "bri": "200", "xy": []interface{}{0.3, 0.3}},
+			map[string]interface{}{"light_id": "2", "bri": "100", "xy": []interface{}{0.4, 0.5}},
+		},
+	}
+
+	got, err := resourceSceneStateUpgradeV0(rawState, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("state = %#v, want %#v", got, want)
+	}
+}
+
+func TestUpgradeLightStateIntsV1(t *testing.T) {
+	cases := []struct {
+		name       string
+		lightState map[string]interface{}
+		want       map[string]interface{}
+	}{
+		{
+			name: "set",
+			lightState: map[string]interface{}{
+				"light_id":       "1",
+				"bri":            "254",
+				"hue":            "0",
+				"sat":            "100",
+				"ct":             "366",
+				"transitiontime": "0",
+			},
+			want: map[string]interface{}{
+				"light_id":       "1",
+				"bri":            254,
+				"hue":            0,
+				"sat":            100,
+				"ct":             366,
+				"transitiontime": 0,
+			},
+		},
+		{
+			name: "unset",
+			lightState: map[string]interface{}{
+				"light_id":       "2",
+				"bri":            "",
+				"hue":            "",
+				"sat":            "lots",
+				"transitiontime": "",
+			},
+			want: map[string]interface{}{
+				"light_id":       "2",
+				"bri":            0,
+				"hue":            unsetLightStateInt,
+				"sat":            unsetLightStateInt,
+				"ct":             0,
+				"transitiontime": unsetLightStateInt,
+			},
+		},
+	}
+
+	for _, c := range cases {
+		upgradeLightStateIntsV1(c.lightState)
+
+		if !reflect.DeepEqual(c.lightState, c.want) {
+			t.Errorf("%s: light state = %#v, want %#v", c.name, c.lightState, c.want)
+		}
+	}
+}
+
+func TestResourceSceneStateUpgradeV0V1(t *testing.T) {
+	rawState := map[string]interface{}{
+		"name": "Evening",
+		"light_state": []interface{}{
+			map[string]interface{}{"light_id": "1", "state": "on", "bri": "200", "xy": []interface{}{0.3}},
+		},
+	}
+
+	want := map[string]interface{}{
+		"name": "Evening",
+		"light_state": []interface{}{
+			map[string]interface{}{
+				"light_id":       "1",
+				"state":          "on",
+				"bri":            200,
+				"hue":            unsetLightStateInt,
+				"sat":            unsetLightStateInt,
+				"ct":             0,
+				"transitiontime": unsetLightStateInt,
+				"xy":             []interface{}{0.3, 0.3},
+			},
+		},
+	}
+
+	got, err := resourceSceneStateUpgradeV0(rawState, nil)
+
+	if err == nil {
+		got, err = resourceSceneStateUpgradeV1(got, nil)
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("state = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,110 @@
+package hue
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/hashicorp/terraform/helper/validation"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/ghue/sdk/rules"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+// Group 0 is every light on the bridge.
+const allLightsGroup = "0"
+
+// resourceSceneRecall recalls a scene once, when it's created.  Everything forces a new one, so changing anything
+// (including triggers) recalls the scene again.
+func resourceSceneRecall() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSceneRecallCreate,
+		Read:   resourceSceneRecallRead,
+		Delete: resourceSceneRecallDelete,
+
+		Schema: map[string]*schema.Schema{
+			"scene_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"group": {
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     allLightsGroup,
+				ForceNew:    true,
+				Description: "Group to recall the scene in.  The default, group 0, is every light in the scene.",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Elem:        &schema.Schema{Type: schema.TypeString},
+				Description: "Changing any of these recalls the scene again.",
+			},
+			"transitiontime": {
+				Type:          schema.TypeInt,
+				Optional:      true,
+				ForceNew:      true,
+				ConflictsWith: []string{"transition"},
+				Description:   "In multiples of 100ms.",
+				ValidateFunc:  validation.IntAtLeast(0),
+			},
+			"transition": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				ForceNew:         true,
+				ConflictsWith:    []string{"transitiontime"},
+				Description:      "How long the lights take to change, as a duration like \"1.5s\".",
+				ValidateFunc:     validateTransition,
+				DiffSuppressFunc: suppressEquivalentTransition,
+			},
+		},
+	}
+}
+
+func resourceSceneRecallCreate(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	sceneId := d.Get("scene_id").(string)
+	groupId := d.Get("group").(string)
+
+	body := rules.ActionBody{
+		Scene: &sceneId,
+	}
+
+	if transitionTime, ok := d.GetOkExists("transitiontime"); ok {
+		transitionTime := transitionTime.(int)
+		body.TransitionTime = &transitionTime
+	}
+
+	if transition := d.Get("transition").(string); transition != "" {
+		transitionTime, err := parseTransition(transition)
+
+		if err != nil {
+			return err
+		}
+
+		body.TransitionTime = &transitionTime
+	}
+
+	err := bridge.Put(connection, fmt.Sprintf("/groups/%s/action", groupId), &body)
+
+	if err != nil {
+		return fmt.Errorf("could not recall scene %s in group %s: %s", sceneId, groupId, err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", groupId, sceneId))
+
+	return nil
+}
+
+// What the lights show after the scene is recalled isn't tracked, so there's nothing to read.
+func resourceSceneRecallRead(d *schema.ResourceData, m interface{}) error {
+	return nil
+}
+
+func resourceSceneRecallDelete(d *schema.ResourceData, m interface{}) error {
+	d.SetId("")
+
+	return nil
+}
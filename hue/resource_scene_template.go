@@ -0,0 +1,374 @@
+package hue
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+	"github.com/hashicorp/terraform/helper/schema"
+	"github.com/lawsontyler/ghue/sdk/common"
+	"github.com/lawsontyler/ghue/sdk/groups"
+	"github.com/lawsontyler/ghue/sdk/scenes"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/lib/bridge"
+)
+
+// resourceSceneTemplate is a GroupScene whose light states come from a palette rather than being listed per light, so
+// the same template can be used for every room and follows the lights in its group.
+func resourceSceneTemplate() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceSceneTemplateCreate,
+		Read:   resourceSceneTemplateRead,
+		Update: resourceSceneTemplateUpdate,
+		Delete: resourceSceneDelete,
+
+		CustomizeDiff: resourceSceneTemplateCustomizeDiff,
+
+		Timeouts: &schema.ResourceTimeout{
+			Delete: schema.DefaultTimeout(5 * time.Minute),
+		},
+
+		Schema: map[string]*schema.Schema{
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"group": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"recycle": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"palette": {
+				Type:     schema.TypeList,
+				Required: true,
+				MinItems: 1,
+				Elem: &schema.Resource{
+					Schema: paletteEntrySchema(),
+				},
+				Description: "Light states to choose from.  Each light gets the entry for its light_type, otherwise the " +
+					"first entry without a light_type that it can show.",
+			},
+
+			"lights": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem:     &schema.Schema{Type: schema.TypeString},
+				Description: "Lights the palette was last applied to.  The bridge adds lights to a GroupScene as they're " +
+					"added to its group, so the lights in the scene itself can't be used to tell which need a light state.",
+			},
+			"light_state": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: computedLightStateSchema(),
+				},
+			},
+			"owner": {
+				Type:     schema.TypeString,
+				Computed: true,
+			},
+			"locked": {
+				Type:     schema.TypeBool,
+				Computed: true,
+			},
+			"wait_for_dependents": waitForDependentsSchema(),
+		},
+	}
+}
+
+func paletteEntrySchema() map[string]*schema.Schema {
+	paletteEntrySchema := defaultUnsetLightStateInts(lightStateSchema())
+
+	paletteEntrySchema["light_type"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Description: "Type of light the entry is for, e.g. \"Extended color light\", \"Color temperature light\" or \"Dimmable light\".",
+	}
+
+	return paletteEntrySchema
+}
+
+// templateLightStates picks a palette entry for each light in the group, as light states ready to store in the scene.
+func templateLightStates(connection *common.Connection, groupId string, palette []interface{}) ([]interface{}, error) {
+	group, hueErr, err := groups.GetGroup(connection, groupId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if hueErr != nil {
+		return nil, fmt.Errorf("bridge returned error %d reading group %s", hueErr.Error.Type, groupId)
+	}
+
+	var lightStates []interface{}
+
+	for _, lightId := range group.Lights {
+		light, err := readLightCapabilities(connection, lightId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := paletteEntryFor(light, lightId, palette)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lightState := make(map[string]interface{})
+
+		for key, value := range entry {
+			lightState[key] = value
+		}
+
+		delete(lightState, "light_type")
+		lightState["light_id"] = lightId
+
+		resolved, err := resolveLightStateColor(connection, lightId, lightState)
+
+		if err != nil {
+			return nil, err
+		}
+
+		lightStates = append(lightStates, resolved)
+	}
+
+	return lightStates, nil
+}
+
+func getSortedLightsInScene(lightStates []interface{}) []string {
+	lights := getLightsInScene(lightStates)
+	sort.Strings(lights)
+
+	return lights
+}
+
+// paletteEntryFor returns the palette entry for the light's type, otherwise the first entry without a light_type that
+// the light can show.
+func paletteEntryFor(light *lightCapabilities, lightId string, palette []interface{}) (map[string]interface{}, error) {
+	for _, entry := range palette {
+		entry := entry.(map[string]interface{})
+
+		if entry["light_type"].(string) == light.Type {
+			return entry, nil
+		}
+	}
+
+	for _, entry := range palette {
+		entry := entry.(map[string]interface{})
+
+		if entry["light_type"].(string) == "" && light.checkAttributes(lightId, setAttributes(entry)) == nil {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no palette entry for light %s (%s), which is a %s", lightId, light.Name, light.Type)
+}
+
+func resourceSceneTemplateCreate(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	groupId := d.Get("group").(string)
+
+	lightStates, err := templateLightStates(connection, groupId, d.Get("palette").([]interface{}))
+
+	if err != nil {
+		return err
+	}
+
+	scene := &bridgeSceneBody{
+		Name:    d.Get("name").(string),
+		Type:    groupScene,
+		Group:   groupId,
+		Recycle: d.Get("recycle").(bool),
+	}
+
+	lightStatesInCreate, err := bridge.SupportsAPIVersion(connection, sceneCreateLightStatesVersion)
+
+	if err != nil {
+		return err
+	}
+
+	if lightStatesInCreate {
+		scene.Lightstates = make(map[string]scenes.LightState)
+
+		for _, lightState := range lightStates {
+			lightState := lightState.(map[string]interface{})
+			scene.Lightstates[lightState["light_id"].(string)] = expandLightState(lightState)
+		}
+	}
+
+	sceneId, err := bridge.Post(connection, "/scenes", scene)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(sceneId)
+
+	if !lightStatesInCreate {
+		if _, err := setLightStates(connection, sceneId, lightStates); err != nil {
+			return err
+		}
+	}
+
+	if err := d.Set("lights", getSortedLightsInScene(lightStates)); err != nil {
+		return err
+	}
+
+	return resourceSceneTemplateRead(d, m)
+}
+
+func resourceSceneTemplateRead(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	var scene bridgeScene
+
+	err := bridge.Get(connection, "/scenes/"+d.Id(), &scene)
+
+	if bridge.IsNotFound(err) {
+		logrus.Warnf("Scene %s not found on the bridge, removing from state", d.Id())
+		d.SetId("")
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	lights := make([]string, 0, len(scene.Lightstates))
+
+	for lightId := range scene.Lightstates {
+		lights = append(lights, lightId)
+	}
+
+	sort.Strings(lights)
+
+	lightStates := make([]map[string]interface{}, 0, len(lights))
+
+	for _, lightId := range lights {
+		state := flattenComputedLightState(lightId, scene.Lightstates[lightId])
+
+		lightStates = append(lightStates, state)
+	}
+
+	values := map[string]interface{}{
+		"name":        scene.Name,
+		"group":       scene.Group,
+		"recycle":     scene.Recycle,
+		"light_state": lightStates,
+		"owner":       scene.Owner,
+		"locked":      scene.Locked,
+	}
+
+	for key, value := range values {
+		if err := d.Set(key, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceSceneTemplateUpdate(d *schema.ResourceData, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	var previous bridgeScene
+
+	err := bridge.Get(connection, "/scenes/"+d.Id(), &previous)
+
+	if err != nil {
+		return err
+	}
+
+	lightStates, err := templateLightStates(connection, d.Get("group").(string), d.Get("palette").([]interface{}))
+
+	if err != nil {
+		return err
+	}
+
+	// A GroupScene's lights follow its group, so only the name and recycle are sent.
+	scene := &bridgeSceneBody{
+		Name:    d.Get("name").(string),
+		Recycle: d.Get("recycle").(bool),
+	}
+
+	err = bridge.Put(connection, "/scenes/"+d.Id(), scene)
+
+	if err != nil {
+		return fmt.Errorf("could not update scene %s: %s", d.Id(), err)
+	}
+
+	clearPreviousEffects(lightStates, &previous)
+
+	stored, err := setLightStates(connection, d.Id(), lightStates)
+
+	if err != nil {
+		return rollbackScene(connection, d.Id(), &previous, nil, stored, err)
+	}
+
+	if err := d.Set("lights", getSortedLightsInScene(lightStates)); err != nil {
+		return err
+	}
+
+	return resourceSceneTemplateRead(d, m)
+}
+
+// resourceSceneTemplateCustomizeDiff checks the palette, then plans new light states when the palette changes or when
+// lights have been added to or removed from the group since the scene was last stored.
+func resourceSceneTemplateCustomizeDiff(d *schema.ResourceDiff, m interface{}) error {
+	connection := m.(*common.Connection)
+
+	for i, entry := range d.Get("palette").([]interface{}) {
+		if err := checkLightStateConflicts(entry.(map[string]interface{})); err != nil {
+			return fmt.Errorf("palette entry %d: %s", i+1, err)
+		}
+	}
+
+	if d.Id() == "" {
+		return nil
+	}
+
+	if d.HasChange("palette") {
+		return d.SetNewComputed("light_state")
+	}
+
+	if !d.NewValueKnown("group") {
+		return nil
+	}
+
+	group, hueErr, err := groups.GetGroup(connection, d.Get("group").(string))
+
+	if err != nil {
+		return err
+	}
+
+	// A missing group is left for the refresh and apply to report.
+	if hueErr != nil {
+		return nil
+	}
+
+	groupLights := append([]string{}, group.Lights...)
+	sort.Strings(groupLights)
+
+	var sceneLights []string
+
+	for _, lightId := range d.Get("lights").([]interface{}) {
+		sceneLights = append(sceneLights, lightId.(string))
+	}
+
+	if fmt.Sprint(groupLights) == fmt.Sprint(sceneLights) {
+		return nil
+	}
+
+	if err := d.SetNew("lights", groupLights); err != nil {
+		return err
+	}
+
+	return d.SetNewComputed("light_state")
+}
@@ -0,0 +1,64 @@
+package hue
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+)
+
+func TestSceneTemplatePaletteConflicts(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{})
+	defer bridge.Close()
+
+	cases := []struct {
+		name    string
+		palette []interface{}
+		wantErr string
+	}{
+		{
+			name: "kelvin and ct",
+			palette: []interface{}{
+				map[string]interface{}{"light_type": "Extended color light", "color": "#ff8800"},
+				map[string]interface{}{"kelvin": 2700, "ct": 370},
+			},
+			wantErr: "palette entry 2: ct and kelvin can't be used together",
+		},
+		{
+			name: "bri and brightness_percent",
+			palette: []interface{}{
+				map[string]interface{}{"bri": 200, "brightness_percent": 80},
+			},
+			wantErr: "palette entry 1: bri and brightness_percent can't be used together",
+		},
+		{
+			name: "one of each",
+			palette: []interface{}{
+				map[string]interface{}{"light_type": "Extended color light", "color": "#ff8800", "bri": 200},
+				map[string]interface{}{"kelvin": 2700, "brightness_percent": 80},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":    "Evening",
+			"group":   "1",
+			"palette": c.palette,
+		})
+
+		if _, errs := resourceSceneTemplate().Validate(config); len(errs) > 0 {
+			t.Errorf("%s: %v", c.name, errs)
+			continue
+		}
+
+		_, err := resourceSceneTemplate().Diff(nil, config, connection)
+
+		if c.wantErr == "" && err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		}
+
+		if c.wantErr != "" && (err == nil || err.Error() != c.wantErr) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.wantErr)
+		}
+	}
+}
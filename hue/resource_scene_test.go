@@ -0,0 +1,365 @@
+package hue
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform/terraform"
+	"github.com/lawsontyler/ghue/sdk/scenes"
+)
+
+func TestRollbackScene(t *testing.T) {
+	on, off := true, false
+	bri, ct := 200, 366
+
+	lightStates := map[string]scenes.LightState{
+		"1": {On: &on, Bri: &bri},
+		"2": {On: &on, CT: &ct},
+		"3": {On: &off},
+	}
+
+	cases := []struct {
+		name        string
+		sceneType   string
+		lights      []string
+		stored      []string
+		failing     string
+		wantLights  []string
+		wantRestore []string
+		wantMessage string
+	}{
+		{
+			name:        "stored and dropped lights",
+			sceneType:   lightScene,
+			lights:      []string{"1", "2", "4"},
+			stored:      []string{"1", "4"},
+			wantLights:  []string{"1", "2", "3"},
+			wantRestore: []string{"1", "3"},
+			wantMessage: "scene ab12 was restored to its previous state",
+		},
+		{
+			name:        "nothing stored",
+			sceneType:   lightScene,
+			lights:      []string{"1", "2", "3"},
+			wantLights:  []string{"1", "2", "3"},
+			wantMessage: "scene ab12 was restored to its previous state",
+		},
+		{
+			name:        "group scene",
+			sceneType:   groupScene,
+			stored:      []string{"1", "2"},
+			wantRestore: []string{"1", "2"},
+			wantMessage: "scene ab12 was restored to its previous state",
+		},
+		{
+			name:        "restore fails",
+			sceneType:   lightScene,
+			lights:      []string{"1", "2"},
+			stored:      []string{"1"},
+			failing:     "3",
+			wantLights:  []string{"1", "2", "3"},
+			wantRestore: []string{"1", "3"},
+			wantMessage: "restoring the previous scene also failed: light 3:",
+		},
+	}
+
+	for _, c := range cases {
+		responses := map[string]string{
+			"PUT /scenes/ab12": `[{"success": {"/scenes/ab12/name": "Evening"}}]`,
+		}
+
+		for lightId := range lightStates {
+			if lightId != c.failing {
+				responses["PUT /scenes/ab12/lightstates/"+lightId] = `[{"success": {}}]`
+			}
+		}
+
+		bridge, connection := newTestBridge(responses)
+
+		previous := &bridgeScene{Name: "Evening", Type: c.sceneType, Lights: []string{"1", "2", "3"}, Lightstates: lightStates}
+
+		err := rollbackScene(connection, "ab12", previous, c.lights, c.stored, errors.New("could not store light 2"))
+
+		bridge.Close()
+
+		if err == nil || !strings.HasPrefix(err.Error(), "could not store light 2; ") || !strings.Contains(err.Error(), c.wantMessage) {
+			t.Errorf("%s: error = %v, want one containing %q", c.name, err, c.wantMessage)
+		}
+
+		sent := bridge.sent("PUT")
+
+		var restore bridgeSceneBody
+
+		if err := json.Unmarshal([]byte(sent["/scenes/ab12"]), &restore); err != nil {
+			t.Fatalf("%s: scene body %q: %s", c.name, sent["/scenes/ab12"], err)
+		}
+
+		if restore.Name != "Evening" || !reflect.DeepEqual(restore.Lights, c.wantLights) {
+			t.Errorf("%s: restored name %q and lights %v, want Evening and %v", c.name, restore.Name, restore.Lights, c.wantLights)
+		}
+
+		var restored []string
+
+		for path, body := range sent {
+			if !strings.HasPrefix(path, "/scenes/ab12/lightstates/") {
+				continue
+			}
+
+			lightId := strings.TrimPrefix(path, "/scenes/ab12/lightstates/")
+			restored = append(restored, lightId)
+
+			var lightState scenes.LightState
+
+			if err := json.Unmarshal([]byte(body), &lightState); err != nil {
+				t.Fatalf("%s: light state body %q: %s", c.name, body, err)
+			}
+
+			if !reflect.DeepEqual(lightState, lightStates[lightId]) {
+				t.Errorf("%s: light %s restored to %s", c.name, lightId, body)
+			}
+		}
+
+		sort.Strings(restored)
+
+		if !reflect.DeepEqual(restored, c.wantRestore) {
+			t.Errorf("%s: restored light states %v, want %v", c.name, restored, c.wantRestore)
+		}
+	}
+}
+
+func TestSceneCaptureCurrentWithLightState(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{
+		"GET /lights/1": `{"name": "Hall", "type": "Extended color light"}`,
+	})
+	defer bridge.Close()
+
+	lightState := []interface{}{map[string]interface{}{"light_id": "1", "state": "on", "bri": 200}}
+
+	cases := []struct {
+		name    string
+		config  map[string]interface{}
+		wantErr string
+	}{
+		{
+			name:    "capture with light_state",
+			config:  map[string]interface{}{"name": "Evening", "capture_current": true, "lights": []interface{}{"1"}, "light_state": lightState},
+			wantErr: "light_state can't be set when capture_current is set",
+		},
+		{
+			name:   "capture",
+			config: map[string]interface{}{"name": "Evening", "capture_current": true, "lights": []interface{}{"1"}},
+		},
+		{
+			name:   "capture_current = false with light_state",
+			config: map[string]interface{}{"name": "Evening", "capture_current": false, "light_state": lightState},
+		},
+		{
+			name:    "neither",
+			config:  map[string]interface{}{"name": "Evening"},
+			wantErr: "at least one light_state is required",
+		},
+	}
+
+	for _, c := range cases {
+		config := terraform.NewResourceConfigRaw(c.config)
+
+		if _, errs := resourceScene().Validate(config); len(errs) > 0 {
+			t.Errorf("%s: %v", c.name, errs)
+			continue
+		}
+
+		_, err := resourceScene().Diff(nil, config, connection)
+
+		if c.wantErr == "" && err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		}
+
+		if c.wantErr != "" && (err == nil || !strings.Contains(err.Error(), c.wantErr)) {
+			t.Errorf("%s: error = %v, want one containing %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestCheckLightStateConflicts(t *testing.T) {
+	cases := []struct {
+		values  map[string]interface{}
+		wantErr string
+	}{
+		{map[string]interface{}{"scene": "abc123"}, ""},
+		{map[string]interface{}{"hue": 8000, "sat": 200, "bri": 200, "transition": "1s"}, ""},
+		{map[string]interface{}{"hue": unsetLightStateInt, "sat": unsetLightStateInt, "ct": 366}, ""},
+		{map[string]interface{}{"hue": 0, "ct": 366}, "hue and ct can't be used together"},
+		{map[string]interface{}{"sat": 200, "kelvin": 2700}, "sat and kelvin can't be used together"},
+		{map[string]interface{}{"xy": []interface{}{0.3, 0.4}, "color": "red", "ct": 366}, "xy and ct and color can't be used together"},
+		{map[string]interface{}{"bri": 200, "brightness_percent": 80}, "bri and brightness_percent can't be used together"},
+		{map[string]interface{}{"transitiontime": 4, "transition": "400ms"}, "transitiontime and transition can't be used together"},
+	}
+
+	for _, c := range cases {
+		err := checkLightStateConflicts(c.values)
+
+		if c.wantErr == "" && err != nil {
+			t.Errorf("checkLightStateConflicts(%v): %s", c.values, err)
+		}
+
+		if c.wantErr != "" && (err == nil || err.Error() != c.wantErr) {
+			t.Errorf("checkLightStateConflicts(%v) = %v, want %q", c.values, err, c.wantErr)
+		}
+	}
+}
+
+func TestSceneLightStateConflicts(t *testing.T) {
+	bridge, connection := newTestBridge(map[string]string{
+		"GET /lights/1": `{"name": "Hall", "type": "Extended color light"}`,
+	})
+	defer bridge.Close()
+
+	cases := []struct {
+		name       string
+		lightState map[string]interface{}
+		wantErr    string
+	}{
+		{
+			name:       "bri and brightness_percent",
+			lightState: map[string]interface{}{"bri": 200, "brightness_percent": 80},
+			wantErr:    "light_state for light 1: bri and brightness_percent can't be used together",
+		},
+		{
+			name:       "color and xy",
+			lightState: map[string]interface{}{"color": "#ff8800", "xy": []interface{}{0.5, 0.4}},
+			wantErr:    "light_state for light 1: xy and color can't be used together",
+		},
+		{
+			name:       "hue of 0 and ct",
+			lightState: map[string]interface{}{"hue": 0, "ct": 366},
+			wantErr:    "light_state for light 1: hue and ct can't be used together",
+		},
+		{
+			name:       "transitiontime and transition",
+			lightState: map[string]interface{}{"transitiontime": 4, "transition": "400ms"},
+			wantErr:    "light_state for light 1: transitiontime and transition can't be used together",
+		},
+		{
+			name:       "hue and sat",
+			lightState: map[string]interface{}{"hue": 8000, "sat": 200, "brightness_percent": 80},
+		},
+	}
+
+	for _, c := range cases {
+		lightState := map[string]interface{}{"light_id": "1", "state": "on"}
+
+		for key, value := range c.lightState {
+			lightState[key] = value
+		}
+
+		config := terraform.NewResourceConfigRaw(map[string]interface{}{
+			"name":        "Evening",
+			"light_state": []interface{}{lightState},
+		})
+
+		if _, errs := resourceScene().Validate(config); len(errs) > 0 {
+			t.Errorf("%s: %v", c.name, errs)
+			continue
+		}
+
+		_, err := resourceScene().Diff(nil, config, connection)
+
+		if c.wantErr == "" && err != nil {
+			t.Errorf("%s: %s", c.name, err)
+		}
+
+		if c.wantErr != "" && (err == nil || err.Error() != c.wantErr) {
+			t.Errorf("%s: error = %v, want %q", c.name, err, c.wantErr)
+		}
+	}
+}
+
+func TestLightStateConflictsWith(t *testing.T) {
+	config := terraform.NewResourceConfigRaw(map[string]interface{}{
+		"light_id":           "1",
+		"bri":                200,
+		"brightness_percent": 80,
+	})
+
+	if _, errs := resourceLightState().Validate(config); len(errs) == 0 {
+		t.Errorf("bri and brightness_percent were accepted together")
+	}
+
+	config = terraform.NewResourceConfigRaw(map[string]interface{}{"light_id": "1", "hue": 8000, "sat": 200})
+
+	if _, errs := resourceLightState().Validate(config); len(errs) > 0 {
+		t.Errorf("hue and sat: %v", errs)
+	}
+}
+
+func TestSceneLightStateHash(t *testing.T) {
+	hash := sceneLightStateHash()
+
+	lightState := func(changes map[string]interface{}) map[string]interface{} {
+		lightState := map[string]interface{}{
+			"light_id":       "1",
+			"state":          "on",
+			"bri":            200,
+			"hue":            unsetLightStateInt,
+			"sat":            unsetLightStateInt,
+			"xy":             []interface{}{0.3123, 0.3293},
+			"transitiontime": unsetLightStateInt,
+			"transition":     "1.5s",
+			"effect":         "",
+		}
+
+		for key, value := range changes {
+			lightState[key] = value
+		}
+
+		return lightState
+	}
+
+	same := []map[string]interface{}{
+		{"xy": []interface{}{0.31234, 0.32926}},
+		{"transition": "1500ms"},
+		{"effect": effectNone},
+	}
+
+	for _, changes := range same {
+		if hash(lightState(changes)) != hash(lightState(nil)) {
+			t.Errorf("light state with %v hashes differently", changes)
+		}
+	}
+
+	different := []map[string]interface{}{
+		{"light_id": "2"},
+		{"bri": 201},
+		{"xy": []interface{}{0.3124, 0.3293}},
+		{"transition": "2s"},
+		{"effect": effectColorLoop},
+	}
+
+	for _, changes := range different {
+		if hash(lightState(changes)) == hash(lightState(nil)) {
+			t.Errorf("light state with %v hashes the same", changes)
+		}
+	}
+}
+
+func TestExpandLightStateLeavesOutUnset(t *testing.T) {
+	got := expandLightState(map[string]interface{}{
+		"light_id":       "1",
+		"state":          "on",
+		"bri":            0,
+		"hue":            unsetLightStateInt,
+		"sat":            0,
+		"ct":             0,
+		"transitiontime": unsetLightStateInt,
+	})
+
+	on, sat := true, 0
+
+	if want := (scenes.LightState{On: &on, Sat: &sat}); !reflect.DeepEqual(got, want) {
+		t.Errorf("expandLightState sent %+v, want only on and sat", got)
+	}
+}
@@ -1,12 +1,25 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/hashicorp/terraform/plugin"
 	"github.com/hashicorp/terraform/terraform"
 	"github.com/lawsontyler/terraform-provider-philips-hue/hue"
+	"github.com/lawsontyler/terraform-provider-philips-hue/hue/export"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "export" {
+		if err := export.Main(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	plugin.Serve(&plugin.ServeOpts{
 		ProviderFunc: func() terraform.ResourceProvider {
 			return hue.Provider()